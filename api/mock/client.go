@@ -2,6 +2,7 @@ package mock
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -10,10 +11,14 @@ import (
 	"net/http/cookiejar"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/AbGuthrie/goquery/v2/api"
+	"github.com/AbGuthrie/goquery/v2/config"
 	"github.com/AbGuthrie/goquery/v2/hosts"
 	"github.com/AbGuthrie/goquery/v2/models"
 
@@ -26,19 +31,63 @@ type MockAPI struct {
 	Client          *http.Client
 	Authed          bool
 	DevelopmentMode bool
+
+	// authMutex guards Authed and serialises authenticating, so concurrent
+	// requests wait for one login rather than each prompting
+	authMutex sync.Mutex
+	// authGeneration counts logins, so a failed request can tell whether another
+	// request has already logged in again since it started
+	authGeneration int
+
+	Server    string
+	SSOServer string
+}
+
+// Settings are the mock backend's options from the apiSettings.mock section of the config file
+type Settings struct {
+	Server          string `json:"server"`
+	SSOServer       string `json:"ssoServer"`
+	DevelopmentMode bool   `json:"developmentMode"`
+}
+
+func init() {
+	api.Register("mock", func(cfg config.Config, rawSettings json.RawMessage) (models.GoQueryAPI, error) {
+		settings := Settings{DevelopmentMode: cfg.DebugEnabled}
+		if len(rawSettings) != 0 {
+			if err := json.Unmarshal(rawSettings, &settings); err != nil {
+				return nil, fmt.Errorf("Invalid mock settings: %s", err)
+			}
+		}
+		return CreateMockAPIWithSettings(settings)
+	})
 }
 
 // CreateMockAPI creates and returns an api implementation that implements the models.GoQueryAPI interface
 // can easily be parameterized with flags passed from main via the config.json
 func CreateMockAPI(developmentMode bool) (models.GoQueryAPI, error) {
+	return CreateMockAPIWithSettings(Settings{DevelopmentMode: developmentMode})
+}
+
+// CreateMockAPIWithSettings is CreateMockAPI with the server locations configurable. Empty
+// servers default to the docker test infra on localhost.
+func CreateMockAPIWithSettings(settings Settings) (models.GoQueryAPI, error) {
+	developmentMode := settings.DevelopmentMode
 	instance := MockAPI{
 		Authed:          false,
 		DevelopmentMode: developmentMode,
+		Server:          strings.TrimRight(settings.Server, "/"),
+		SSOServer:       strings.TrimRight(settings.SSOServer, "/"),
+	}
+	if instance.Server == "" {
+		instance.Server = "https://localhost:8001"
+	}
+	if instance.SSOServer == "" {
+		instance.SSOServer = "http://localhost:8002"
 	}
 
 	instance.CookieJar, _ = cookiejar.New(nil)
 	if developmentMode {
-		fmt.Println("Warning: Debug is enabled, setting InsecureSkipVerify: True for auth request client!")
+		fmt.Fprintln(os.Stderr, "Warning: Debug is enabled, setting InsecureSkipVerify: True for auth request client!")
 	}
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{
@@ -53,13 +102,13 @@ func CreateMockAPI(developmentMode bool) (models.GoQueryAPI, error) {
 func credentials() (string, string) {
 	reader := bufio.NewReader(os.Stdin)
 
-	fmt.Print("Username: ")
+	fmt.Fprint(os.Stderr, "Username: ")
 	username, _ := reader.ReadString('\n')
 
-	fmt.Print("Password: ")
+	fmt.Fprint(os.Stderr, "Password: ")
 	bytePassword, _ := terminal.ReadPassword(int(syscall.Stdin))
 	password := string(bytePassword)
-	fmt.Printf("\n")
+	fmt.Fprintf(os.Stderr, "\n")
 	return strings.TrimSpace(username), password
 }
 
@@ -93,7 +142,7 @@ func extractSSOResponse(response *http.Response, debug bool) (string, string, er
 		return "", "", fmt.Errorf("Credential Failure")
 	}
 	if debug {
-		fmt.Printf("ssoResponse: %s\n", bodyStr)
+		fmt.Fprintf(os.Stderr, "ssoResponse: %s\n", bodyStr)
 	}
 	// Hacky Extracts
 	loc := strings.Index(bodyStr, "name=\"SAMLResponse\"")
@@ -106,28 +155,43 @@ func extractSSOResponse(response *http.Response, debug bool) (string, string, er
 	return ssoResponse, relayState, nil
 }
 
-func (instance *MockAPI) authenticate() error {
-	response, err := instance.Client.Get("https://localhost:8001/checkHost")
+// postForm issues a form encoded POST request that is aborted when ctx is done
+func (instance *MockAPI) postForm(ctx context.Context, endpoint string, data url.Values) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return instance.Client.Do(request)
+}
+
+func (instance *MockAPI) authenticate(ctx context.Context) error {
+	request, err := http.NewRequestWithContext(ctx, "GET", instance.Server+"/checkHost", nil)
+	if err != nil {
+		return fmt.Errorf("Authentication failed: %s", err)
+	}
+	response, err := instance.Client.Do(request)
 	if err != nil {
 		return fmt.Errorf("Authentication failed: %s", err)
 	}
 
-	fmt.Printf("Authenticating with backend...\n")
+	fmt.Fprintf(os.Stderr, "Authenticating with backend...\n")
 	ssoRequest, relayState := extractSSORequest(response)
 
 	if ssoRequest == "" && relayState == "" {
 		// Looks like the user is already authed, there was no SAML data
 		instance.Authed = true
+		instance.authGeneration++
 		return nil
 	}
 
 	if instance.DevelopmentMode {
-		fmt.Printf("ssoRequest: %s\nrelayState: %s\n", ssoRequest, relayState)
+		fmt.Fprintf(os.Stderr, "ssoRequest: %s\nrelayState: %s\n", ssoRequest, relayState)
 	}
 
 	username, password := credentials()
 
-	response, err = instance.Client.PostForm("http://localhost:8002/sso",
+	response, err = instance.postForm(ctx, instance.SSOServer+"/sso",
 		url.Values{"SAMLRequest": {ssoRequest}, "RelayState": {relayState}, "user": {username}, "password": {password}},
 	)
 	if err != nil {
@@ -136,36 +200,88 @@ func (instance *MockAPI) authenticate() error {
 
 	samlResponse, relayState, err := extractSSOResponse(response, instance.DevelopmentMode)
 	if instance.DevelopmentMode {
-		fmt.Printf("ssoResponse: %s\nrelayState: %s\n", samlResponse, relayState)
+		fmt.Fprintf(os.Stderr, "ssoResponse: %s\nrelayState: %s\n", samlResponse, relayState)
 	}
 
 	if err != nil {
 		return err
 	}
 
-	response, err = instance.Client.PostForm("https://localhost:8001/saml/acs",
+	response, err = instance.postForm(ctx, instance.Server+"/saml/acs",
 		url.Values{"SAMLResponse": {samlResponse}, "RelayState": {relayState}},
 	)
 
-	if instance.DevelopmentMode {
-		fmt.Printf("samlResponse: %s\nrelayState: %s\n", response, relayState)
-	}
-
 	if err != nil {
 		return fmt.Errorf("Authentication failed: %s", err)
 	}
+	response.Body.Close()
 
-	fmt.Printf("Authentication Complete\n")
+	if instance.DevelopmentMode {
+		fmt.Fprintf(os.Stderr, "acsResponse: %s\nrelayState: %s\n", response.Status, relayState)
+	}
+
+	fmt.Fprintf(os.Stderr, "Authentication Complete\n")
 	instance.Authed = true
+	instance.authGeneration++
 	return nil
 }
 
+// ensureAuthenticated authenticates with the backend unless already authenticated,
+// returning the login generation for reauthenticate and deauthenticate. Requests
+// made with a context from models.WithoutAuthentication get
+// models.ErrNotAuthenticated instead of prompting.
+func (instance *MockAPI) ensureAuthenticated(ctx context.Context) (int, error) {
+	instance.authMutex.Lock()
+	defer instance.authMutex.Unlock()
+	if instance.Authed {
+		return instance.authGeneration, nil
+	}
+	if !models.AuthenticationAllowed(ctx) {
+		return instance.authGeneration, models.ErrNotAuthenticated
+	}
+	err := instance.authenticate(ctx)
+	return instance.authGeneration, err
+}
+
+// reauthenticate logs in again after a request made in login generation failed
+// with err, which may have been because the session expired, unless another
+// request has logged in since. err is returned, along with why logging in failed.
+func (instance *MockAPI) reauthenticate(ctx context.Context, generation int, err error) error {
+	// A cancelled request is not an authentication failure
+	if ctx.Err() != nil || !models.AuthenticationAllowed(ctx) {
+		return err
+	}
+	instance.authMutex.Lock()
+	defer instance.authMutex.Unlock()
+	if instance.authGeneration != generation {
+		return err
+	}
+	if authErr := instance.authenticate(ctx); authErr != nil {
+		return fmt.Errorf("%s, then logging in again failed: %s", err, authErr)
+	}
+	return err
+}
+
+// deauthenticate makes the next request log in again, unless another request
+// logged in since the failed request made in login generation
+func (instance *MockAPI) deauthenticate(generation int) {
+	instance.authMutex.Lock()
+	defer instance.authMutex.Unlock()
+	if instance.authGeneration == generation {
+		instance.Authed = false
+	}
+}
+
+// CheckHost implements models.GoQueryAPI
 func (instance *MockAPI) CheckHost(uuid string) (hosts.Host, error) {
-	if !instance.Authed {
-		err := instance.authenticate()
-		if err != nil {
-			return hosts.Host{}, err
-		}
+	return instance.CheckHostContext(context.Background(), uuid)
+}
+
+// CheckHostContext implements models.GoQueryContextAPI
+func (instance *MockAPI) CheckHostContext(ctx context.Context, uuid string) (hosts.Host, error) {
+	generation, err := instance.ensureAuthenticated(ctx)
+	if err != nil {
+		return hosts.Host{}, err
 	}
 	type APIHost struct {
 		UUID           string `json:"UUID"`
@@ -175,7 +291,7 @@ func (instance *MockAPI) CheckHost(uuid string) (hosts.Host, error) {
 		Version        string `json:"Version"`
 	}
 
-	response, err := instance.Client.PostForm("https://localhost:8001/checkHost",
+	response, err := instance.postForm(ctx, instance.Server+"/checkHost",
 		url.Values{"uuid": {uuid}},
 	)
 	if err != nil {
@@ -197,10 +313,10 @@ func (instance *MockAPI) CheckHost(uuid string) (hosts.Host, error) {
 	err = json.Unmarshal(bodyBytes, &hostResponse)
 	if err != nil {
 		if instance.DevelopmentMode {
-			fmt.Printf("Returned Body: %s\n", string(bodyBytes))
+			fmt.Fprintf(os.Stderr, "Returned Body: %s\n", string(bodyBytes))
 		}
 		// Probable authentication failure
-		instance.Authed = false
+		instance.deauthenticate(generation)
 		return hosts.Host{}, err
 	}
 
@@ -209,29 +325,32 @@ func (instance *MockAPI) CheckHost(uuid string) (hosts.Host, error) {
 		ComputerName:     hostResponse.ComputerName,
 		Platform:         hostResponse.Platform,
 		Version:          hostResponse.Version,
-		CurrentDirectory: "/",
+		CurrentDirectory: hosts.PathsFor(hostResponse.Platform).Root(),
 	}, nil
 }
 
+// ScheduleQuery implements models.GoQueryAPI
 func (instance *MockAPI) ScheduleQuery(uuid string, query string) (string, error) {
-	if !instance.Authed {
-		err := instance.authenticate()
-		if err != nil {
-			return "", err
-		}
+	return instance.ScheduleQueryContext(context.Background(), uuid, query)
+}
+
+// ScheduleQueryContext implements models.GoQueryContextAPI
+func (instance *MockAPI) ScheduleQueryContext(ctx context.Context, uuid string, query string) (string, error) {
+	generation, err := instance.ensureAuthenticated(ctx)
+	if err != nil {
+		return "", err
 	}
 	type QueryScheduleResponse struct {
 		QueryName string `json:"queryName"`
 	}
 
-	response, err := instance.Client.PostForm("https://localhost:8001/scheduleQuery",
+	response, err := instance.postForm(ctx, instance.Server+"/scheduleQuery",
 		url.Values{
 			"uuid":  {uuid},
 			"query": {query}},
 	)
 	if err != nil {
-		instance.authenticate()
-		return "", fmt.Errorf("ScheduleQuery call failed: %s", err)
+		return "", instance.reauthenticate(ctx, generation, fmt.Errorf("ScheduleQuery call failed: %s", err))
 	}
 	if response.StatusCode == 404 {
 		return "", fmt.Errorf("Unknown Host")
@@ -247,53 +366,201 @@ func (instance *MockAPI) ScheduleQuery(uuid string, query string) (string, error
 	qsResponse := QueryScheduleResponse{}
 	err = json.Unmarshal(bodyBytes, &qsResponse)
 	if err != nil {
-		instance.Authed = false
+		instance.deauthenticate(generation)
 		return "", err
 	}
-	hosts.AddQueryToHost(uuid, hosts.Query{Name: qsResponse.QueryName, SQL: query})
 	return qsResponse.QueryName, nil
 }
 
+// FetchResults implements models.GoQueryAPI
 func (instance *MockAPI) FetchResults(queryName string) ([]map[string]string, string, error) {
+	results, status, err := instance.FetchResultsContext(context.Background(), queryName)
+	return results, status.String(), err
+}
+
+// FetchResultsContext implements models.GoQueryContextAPI
+func (instance *MockAPI) FetchResultsContext(ctx context.Context, queryName string) ([]map[string]string, models.QueryStatus, error) {
+	results, status, err := instance.FetchOrderedResultsContext(ctx, queryName)
+	return results.Rows, status, err
+}
+
+// FetchOrderedResultsContext implements models.OrderedResultsAPI. goserver passes on
+// the results exactly as osquery sent them, so their column order is kept.
+func (instance *MockAPI) FetchOrderedResultsContext(ctx context.Context, queryName string) (models.Results, models.QueryStatus, error) {
 	type ResultsResponse struct {
-		Rows   []map[string]string `json:"results"`
-		Status string              `json:"status"`
+		Rows       json.RawMessage `json:"results"`
+		Status     string          `json:"status"`
+		StatusCode int             `json:"statusCode"`
+		Message    string          `json:"message"`
 	}
 	resultsResponse := ResultsResponse{}
+	results := models.Results{}
 
-	if !instance.Authed {
-		err := instance.authenticate()
-		if err != nil {
-			return resultsResponse.Rows, "", err
-		}
+	generation, err := instance.ensureAuthenticated(ctx)
+	if err != nil {
+		return results, models.QueryStatus{}, err
 	}
 
-	response, err := instance.Client.PostForm(
-		"https://localhost:8001/fetchResults",
+	response, err := instance.postForm(
+		ctx,
+		instance.Server+"/fetchResults",
 		url.Values{"queryName": {queryName}},
 	)
 
 	if err != nil {
-		instance.authenticate()
-		return resultsResponse.Rows, "", fmt.Errorf("FetchResults call failed: %s", err)
+		return results, models.QueryStatus{},
+			instance.reauthenticate(ctx, generation, fmt.Errorf("FetchResults call failed: %s", err))
 	}
 	if response.StatusCode == 404 {
-		return resultsResponse.Rows, "", fmt.Errorf("Unknown queryName")
+		return results, models.QueryStatus{}, models.ErrUnknownQuery
 	}
 	if response.StatusCode != 200 {
-		return resultsResponse.Rows, "", fmt.Errorf("Server returned unknown error: %d", response.StatusCode)
+		return results, models.QueryStatus{}, fmt.Errorf("Server returned unknown error: %d", response.StatusCode)
 	}
 
 	bodyBytes, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return resultsResponse.Rows, "", fmt.Errorf("Could not read fetchResults response")
+		return results, models.QueryStatus{}, fmt.Errorf("Could not read fetchResults response")
 	}
 
 	if err := json.Unmarshal(bodyBytes, &resultsResponse); err != nil {
-		instance.Authed = false
-		return resultsResponse.Rows, "", err
+		instance.deauthenticate(generation)
+		return results, models.QueryStatus{}, err
+	}
+
+	results, err = models.DecodeResults(resultsResponse.Rows)
+	if err != nil {
+		return results, models.QueryStatus{}, fmt.Errorf("Could not parse query results: %s", err)
+	}
+
+	switch resultsResponse.Status {
+	case "Pending":
+		return results, models.StatusPending, nil
+	case "Complete":
+		return results, models.StatusComplete, nil
+	case "Failed":
+		return results, models.StatusFailed(resultsResponse.StatusCode, resultsResponse.Message), nil
+	}
+	// Older goserver builds only send a free form status string
+	return results, models.ParseQueryStatus(resultsResponse.Status), nil
+}
+
+// CancelQueryContext implements models.QueryCanceller
+func (instance *MockAPI) CancelQueryContext(ctx context.Context, queryName string) error {
+	generation, err := instance.ensureAuthenticated(ctx)
+	if err != nil {
+		return err
+	}
+
+	response, err := instance.postForm(
+		ctx,
+		instance.Server+"/cancelQuery",
+		url.Values{"queryName": {queryName}},
+	)
+
+	if err != nil {
+		return instance.reauthenticate(ctx, generation, fmt.Errorf("CancelQuery call failed: %s", err))
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case 200:
+		return nil
+	case 404:
+		return models.ErrUnknownQuery
+	case 409:
+		return fmt.Errorf("Query has already been delivered to the host")
+	}
+	return fmt.Errorf("Server returned unknown error: %d", response.StatusCode)
+}
+
+// ListCarvesContext implements models.CarveAPI
+func (instance *MockAPI) ListCarvesContext(ctx context.Context, uuid string) ([]models.Carve, error) {
+	type APICarve struct {
+		SessionID      string `json:"SessionID"`
+		CarveID        string `json:"CarveID"`
+		RequestID      string `json:"RequestID"`
+		UUID           string `json:"UUID"`
+		Size           int64  `json:"Size"`
+		BlockSize      int64  `json:"BlockSize"`
+		BlockCount     int    `json:"BlockCount"`
+		BlocksReceived int    `json:"BlocksReceived"`
+	}
+
+	generation, err := instance.ensureAuthenticated(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Return QueryResultsResponse type (outer caller should check .Status)
-	return resultsResponse.Rows, resultsResponse.Status, nil
+	response, err := instance.postForm(ctx, instance.Server+"/listCarves",
+		url.Values{"uuid": {uuid}},
+	)
+	if err != nil {
+		return nil, instance.reauthenticate(ctx, generation, fmt.Errorf("ListCarves call failed: %s", err))
+	}
+	defer response.Body.Close()
+	if response.StatusCode == 404 {
+		return nil, fmt.Errorf("Unknown Host")
+	}
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("Server returned unknown error: %d", response.StatusCode)
+	}
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read response")
+	}
+	carvesResponse := []APICarve{}
+	if err := json.Unmarshal(bodyBytes, &carvesResponse); err != nil {
+		instance.deauthenticate(generation)
+		return nil, err
+	}
+
+	carves := make([]models.Carve, 0, len(carvesResponse))
+	for _, carve := range carvesResponse {
+		carves = append(carves, models.Carve{
+			ID:             carve.SessionID,
+			GUID:           carve.CarveID,
+			RequestID:      carve.RequestID,
+			UUID:           carve.UUID,
+			Size:           carve.Size,
+			BlockSize:      carve.BlockSize,
+			BlockCount:     carve.BlockCount,
+			BlocksReceived: carve.BlocksReceived,
+		})
+	}
+	return carves, nil
+}
+
+// FetchCarveBlockContext implements models.CarveAPI
+func (instance *MockAPI) FetchCarveBlockContext(ctx context.Context, carveID string, block int) ([]byte, error) {
+	generation, err := instance.ensureAuthenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := instance.postForm(ctx, instance.Server+"/fetchCarveBlock",
+		url.Values{"carveID": {carveID}, "block": {strconv.Itoa(block)}},
+	)
+	if err != nil {
+		return nil, instance.reauthenticate(ctx, generation, fmt.Errorf("FetchCarveBlock call failed: %s", err))
+	}
+	defer response.Body.Close()
+	if response.StatusCode == 404 {
+		return nil, fmt.Errorf("Block %d of carve %s has not been received", block, carveID)
+	}
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("Server returned unknown error: %d", response.StatusCode)
+	}
+	// An expired SSO session is answered with a login page rather than the block
+	if !strings.HasPrefix(response.Header.Get("Content-Type"), "application/octet-stream") {
+		instance.deauthenticate(generation)
+		return nil, fmt.Errorf("FetchCarveBlock call failed: unexpected response")
+	}
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read fetchCarveBlock response")
+	}
+	return data, nil
 }
@@ -0,0 +1,57 @@
+package mock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+// testServer is a mock backend that is already logged in and never answers
+// checkHost until the request is abandoned
+func testServer(t *testing.T) *MockAPI {
+	stop := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+		case <-stop:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(stop) })
+
+	api, err := CreateMockAPIWithSettings(Settings{Server: server.URL, SSOServer: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api.(*MockAPI)
+}
+
+func TestCancelAbortsRequests(t *testing.T) {
+	api := testServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := api.CheckHostContext(ctx, "host"); err == nil {
+		t.Fatalf("CheckHostContext succeeded against a server that never answers")
+	}
+	// The client's own timeout is 10 seconds
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("CheckHostContext returned after %s, want it aborted at the deadline", elapsed)
+	}
+}
+
+func TestWithoutAuthentication(t *testing.T) {
+	api := testServer(t)
+	ctx := models.WithoutAuthentication(context.Background())
+	if _, err := api.CheckHostContext(ctx, "host"); err != models.ErrNotAuthenticated {
+		t.Errorf("CheckHostContext without authentication = %v, want %v", err, models.ErrNotAuthenticated)
+	}
+}
@@ -3,6 +3,7 @@ package osctrl
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -11,9 +12,12 @@ import (
 	"net/http/cookiejar"
 	"os"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/AbGuthrie/goquery/v2/api"
+	"github.com/AbGuthrie/goquery/v2/config"
 	"github.com/AbGuthrie/goquery/v2/models"
 
 	"github.com/AbGuthrie/goquery/v2/hosts"
@@ -33,29 +37,73 @@ type OSctrlAPI struct {
 	Client    *http.Client
 	Authed    bool
 
+	// authMutex guards Token and Authed and serialises authenticating, so
+	// concurrent requests wait for one login rather than each prompting
+	authMutex sync.Mutex
+	// authGeneration counts logins, so a failed request can tell whether another
+	// request has already logged in again since it started
+	authGeneration int
+
 	Protocol  string
 	Server    string
 	AdminBase string
 	APIBase   string
 }
 
+// Settings are the osctrl backend's options from the apiSettings.osctrl section of the config file
+type Settings struct {
+	Protocol        string `json:"protocol"`
+	AdminServer     string `json:"adminServer"`
+	APIServer       string `json:"apiServer"`
+	DevelopmentMode bool   `json:"developmentMode"`
+}
+
+func init() {
+	api.Register("osctrl", func(cfg config.Config, rawSettings json.RawMessage) (models.GoQueryAPI, error) {
+		settings := Settings{DevelopmentMode: cfg.DebugEnabled}
+		if len(rawSettings) != 0 {
+			if err := json.Unmarshal(rawSettings, &settings); err != nil {
+				return nil, fmt.Errorf("Invalid osctrl settings: %s", err)
+			}
+		}
+		return CreateOSctrlAPIWithSettings(settings)
+	})
+}
+
 // CreateOSctrlAPI creates and returns an api implementation that implements the models.GoQueryAPI interface
 // can easily be parameterized with flags passed from main via the config.json
 func CreateOSctrlAPI(developmentMode bool) (models.GoQueryAPI, error) {
-	adminServer := "osctrl-admin.domain.tld"
-	protocol := "https"
-	apiServer := "osctrl-api.domain.tld"
+	return CreateOSctrlAPIWithSettings(Settings{DevelopmentMode: developmentMode})
+}
+
+// CreateOSctrlAPIWithSettings is CreateOSctrlAPI with the osctrl servers configurable. Empty
+// settings fall back to placeholder domains.
+func CreateOSctrlAPIWithSettings(settings Settings) (models.GoQueryAPI, error) {
+	developmentMode := settings.DevelopmentMode
+	protocol := settings.Protocol
+	if protocol == "" {
+		protocol = "https"
+	}
+	adminServer := settings.AdminServer
+	if adminServer == "" {
+		adminServer = "osctrl-admin.domain.tld"
+	}
+	apiServer := settings.APIServer
+	if apiServer == "" {
+		apiServer = "osctrl-api.domain.tld"
+	}
 
 	instance := OSctrlAPI{
 		Authed:    false,
 		Protocol:  protocol,
+		Server:    apiServer,
 		AdminBase: fmt.Sprintf("%s://%s", protocol, adminServer),
 		APIBase:   fmt.Sprintf("%s://%s", protocol, apiServer),
 	}
 
 	instance.CookieJar, _ = cookiejar.New(nil)
 	if developmentMode {
-		fmt.Println("Warning: developmentMode is enabled, setting InsecureSkipVerify: True for auth request client!")
+		fmt.Fprintln(os.Stderr, "Warning: developmentMode is enabled, setting InsecureSkipVerify: True for auth request client!")
 	}
 
 	tr := &http.Transport{
@@ -71,18 +119,19 @@ func CreateOSctrlAPI(developmentMode bool) (models.GoQueryAPI, error) {
 func credentials() (string, string) {
 	reader := bufio.NewReader(os.Stdin)
 
-	fmt.Print("Username: ")
+	fmt.Fprint(os.Stderr, "Username: ")
 	username, _ := reader.ReadString('\n')
 
-	fmt.Print("Password: ")
+	fmt.Fprint(os.Stderr, "Password: ")
 	bytePassword, _ := terminal.ReadPassword(int(syscall.Stdin))
 	password := string(bytePassword)
-	fmt.Printf("\n")
+	fmt.Fprintf(os.Stderr, "\n")
 	return strings.TrimSpace(username), password
 }
 
-func (instance *OSctrlAPI) authenticate() error {
-	response, err := instance.Client.Get(instance.AdminBase)
+func (instance *OSctrlAPI) authenticate(ctx context.Context) error {
+	request, _ := http.NewRequestWithContext(ctx, "GET", instance.AdminBase, nil)
+	response, err := instance.Client.Do(request)
 
 	if err != nil {
 		return fmt.Errorf("Couldn't find osctrl-amdin service: %s", err)
@@ -90,10 +139,11 @@ func (instance *OSctrlAPI) authenticate() error {
 
 	username, _ /*password*/ := credentials()
 	// Complete your authentication flow
-	fmt.Println("Login Complete")
-	fmt.Println("Getting osctrl Token")
+	fmt.Fprintln(os.Stderr, "Login Complete")
+	fmt.Fprintln(os.Stderr, "Getting osctrl Token")
 
-	response, err = instance.Client.Get(fmt.Sprintf("%s/tokens/%s", instance.AdminBase, username))
+	request, _ = http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/tokens/%s", instance.AdminBase, username), nil)
+	response, err = instance.Client.Do(request)
 	if err != nil {
 		return fmt.Errorf("Auth call failed: %s", err)
 	}
@@ -123,18 +173,74 @@ func (instance *OSctrlAPI) authenticate() error {
 		return fmt.Errorf("Token returned was empty")
 	}
 
-	fmt.Println("Gathered Token Successfully")
-	fmt.Printf("%s\n", instance.Token.Token)
+	fmt.Fprintln(os.Stderr, "Gathered Token Successfully")
 	instance.Authed = true
+	instance.authGeneration++
 	return nil
 }
 
+// ensureAuthenticated authenticates with osctrl unless already authenticated,
+// returning the login generation for reauthenticate and deauthenticate. Requests
+// made with a context from models.WithoutAuthentication get
+// models.ErrNotAuthenticated instead of prompting.
+func (instance *OSctrlAPI) ensureAuthenticated(ctx context.Context) (int, error) {
+	instance.authMutex.Lock()
+	defer instance.authMutex.Unlock()
+	if instance.Authed {
+		return instance.authGeneration, nil
+	}
+	if !models.AuthenticationAllowed(ctx) {
+		return instance.authGeneration, models.ErrNotAuthenticated
+	}
+	err := instance.authenticate(ctx)
+	return instance.authGeneration, err
+}
+
+// reauthenticate logs in again after a request made in login generation failed
+// with err, which may have been because the token expired, unless another
+// request has logged in since. err is returned, along with why logging in failed.
+func (instance *OSctrlAPI) reauthenticate(ctx context.Context, generation int, err error) error {
+	// A cancelled request is not an authentication failure
+	if ctx.Err() != nil || !models.AuthenticationAllowed(ctx) {
+		return err
+	}
+	instance.authMutex.Lock()
+	defer instance.authMutex.Unlock()
+	if instance.authGeneration != generation {
+		return err
+	}
+	if authErr := instance.authenticate(ctx); authErr != nil {
+		return fmt.Errorf("%s, then logging in again failed: %s", err, authErr)
+	}
+	return err
+}
+
+// deauthenticate makes the next request log in again, unless another request
+// logged in since the failed request made in login generation
+func (instance *OSctrlAPI) deauthenticate(generation int) {
+	instance.authMutex.Lock()
+	defer instance.authMutex.Unlock()
+	if instance.authGeneration == generation {
+		instance.Authed = false
+	}
+}
+
+// bearer returns the Authorization header value for the current token
+func (instance *OSctrlAPI) bearer() string {
+	instance.authMutex.Lock()
+	defer instance.authMutex.Unlock()
+	return fmt.Sprintf("Bearer %s", instance.Token.Token)
+}
+
+// CheckHost implements models.GoQueryAPI
 func (instance *OSctrlAPI) CheckHost(uuid string) (hosts.Host, error) {
-	if !instance.Authed {
-		err := instance.authenticate()
-		if err != nil {
-			return hosts.Host{}, err
-		}
+	return instance.CheckHostContext(context.Background(), uuid)
+}
+
+// CheckHostContext implements models.GoQueryContextAPI
+func (instance *OSctrlAPI) CheckHostContext(ctx context.Context, uuid string) (hosts.Host, error) {
+	if _, err := instance.ensureAuthenticated(ctx); err != nil {
+		return hosts.Host{}, err
 	}
 	type APIHost struct {
 		ComputerName   string `json:"Localname"`
@@ -145,8 +251,8 @@ func (instance *OSctrlAPI) CheckHost(uuid string) (hosts.Host, error) {
 		Version        string `json:"OsqueryVersion"`
 	}
 
-	request, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/nodes/%s", instance.APIBase, uuid), nil)
-	request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", instance.Token.Token))
+	request, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/nodes/%s", instance.APIBase, uuid), nil)
+	request.Header.Add("Authorization", instance.bearer())
 	request.Header.Set("User-Agent", "goquery/1.0")
 	response, err := instance.Client.Do(request)
 
@@ -182,16 +288,20 @@ func (instance *OSctrlAPI) CheckHost(uuid string) (hosts.Host, error) {
 		Platform:         hostResponse.Platform,
 		Version:          hostResponse.Version,
 		Username:         hostResponse.Username,
-		CurrentDirectory: "/",
+		CurrentDirectory: hosts.PathsFor(hostResponse.Platform).Root(),
 	}, nil
 }
 
+// ScheduleQuery implements models.GoQueryAPI
 func (instance *OSctrlAPI) ScheduleQuery(uuid string, query string) (string, error) {
-	if !instance.Authed {
-		err := instance.authenticate()
-		if err != nil {
-			return "", err
-		}
+	return instance.ScheduleQueryContext(context.Background(), uuid, query)
+}
+
+// ScheduleQueryContext implements models.GoQueryContextAPI
+func (instance *OSctrlAPI) ScheduleQueryContext(ctx context.Context, uuid string, query string) (string, error) {
+	generation, err := instance.ensureAuthenticated(ctx)
+	if err != nil {
+		return "", err
 	}
 	type QueryScheduleResponse struct {
 		QueryName string `json:"query_name"`
@@ -204,14 +314,13 @@ func (instance *OSctrlAPI) ScheduleQuery(uuid string, query string) (string, err
 	queryRequest := DistributedQueryRequest{UUIDs: []string{uuid}, Query: query}
 	qrJSON, _ := json.Marshal(queryRequest)
 
-	request, _ := http.NewRequest("POST", fmt.Sprintf("%s/api/v1/queries", instance.APIBase), bytes.NewReader(qrJSON))
-	request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", instance.Token.Token))
+	request, _ := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/api/v1/queries", instance.APIBase), bytes.NewReader(qrJSON))
+	request.Header.Add("Authorization", instance.bearer())
 	request.Header.Set("User-Agent", "goquery/1.0")
 	response, err := instance.Client.Do(request)
 
 	if err != nil {
-		instance.authenticate()
-		return "", fmt.Errorf("ScheduleQuery call failed: %s", err)
+		return "", instance.reauthenticate(ctx, generation, fmt.Errorf("ScheduleQuery call failed: %s", err))
 	}
 	if response.StatusCode == 404 {
 		return "", fmt.Errorf("Unknown Host")
@@ -227,68 +336,86 @@ func (instance *OSctrlAPI) ScheduleQuery(uuid string, query string) (string, err
 	qsResponse := QueryScheduleResponse{}
 	err = json.Unmarshal(bodyBytes, &qsResponse)
 	if err != nil {
-		instance.Authed = false
+		instance.deauthenticate(generation)
 		return "", err
 	}
-	hosts.AddQueryToHost(uuid, hosts.Query{Name: qsResponse.QueryName, SQL: query})
 	return qsResponse.QueryName, nil
 }
 
+// FetchResults implements models.GoQueryAPI
 func (instance *OSctrlAPI) FetchResults(queryName string) ([]map[string]string, string, error) {
+	results, status, err := instance.FetchResultsContext(context.Background(), queryName)
+	return results, status.String(), err
+}
+
+// FetchResultsContext implements models.GoQueryContextAPI
+func (instance *OSctrlAPI) FetchResultsContext(ctx context.Context, queryName string) ([]map[string]string, models.QueryStatus, error) {
+	results, status, err := instance.FetchOrderedResultsContext(ctx, queryName)
+	return results.Rows, status, err
+}
+
+// FetchOrderedResultsContext implements models.OrderedResultsAPI, keeping the column
+// order of the results osctrl stored from osquery
+func (instance *OSctrlAPI) FetchOrderedResultsContext(ctx context.Context, queryName string) (models.Results, models.QueryStatus, error) {
 	type ResultsResponse struct {
-		Rows   []map[string]string `json:"result"`
-		Status int                 `json:"status"`
-		Name   string              `json:"name"`
+		Rows   json.RawMessage `json:"result"`
+		Status int             `json:"status"`
+		Name   string          `json:"name"`
 	}
 
 	type MachineResults = map[string]ResultsResponse
 
-	if !instance.Authed {
-		err := instance.authenticate()
-		if err != nil {
-			return []map[string]string{}, "", err
-		}
+	generation, err := instance.ensureAuthenticated(ctx)
+	if err != nil {
+		return models.Results{}, models.QueryStatus{}, err
 	}
 
-	request, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/queries/results/%s", instance.APIBase, queryName), nil)
-	request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", instance.Token.Token))
+	request, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/queries/results/%s", instance.APIBase, queryName), nil)
+	request.Header.Add("Authorization", instance.bearer())
 	request.Header.Set("User-Agent", "goquery/1.0")
 	response, err := instance.Client.Do(request)
 
 	if err != nil {
-		instance.authenticate()
-		return []map[string]string{}, "", fmt.Errorf("FetchResults call failed: %s", err)
+		return models.Results{}, models.QueryStatus{},
+			instance.reauthenticate(ctx, generation, fmt.Errorf("FetchResults call failed: %s", err))
 	}
 	if response.StatusCode == 404 {
-		return []map[string]string{}, "", fmt.Errorf("Unknown queryName")
+		return models.Results{}, models.QueryStatus{}, models.ErrUnknownQuery
 	}
 	if response.StatusCode != 200 {
-		return []map[string]string{}, "", fmt.Errorf("Server returned unknown error: %d", response.StatusCode)
+		return models.Results{}, models.QueryStatus{}, fmt.Errorf("Server returned unknown error: %d", response.StatusCode)
 	}
 
 	bodyBytes, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return []map[string]string{}, "", fmt.Errorf("Could not read fetchResults response")
+		return models.Results{}, models.QueryStatus{}, fmt.Errorf("Could not read fetchResults response")
 	}
 
 	apiResponse := MachineResults{}
 	if err := json.Unmarshal(bodyBytes, &apiResponse); err != nil {
-		instance.Authed = false
-		return []map[string]string{}, "", err
+		instance.deauthenticate(generation)
+		return models.Results{}, models.QueryStatus{}, err
 	}
 
 	if len(apiResponse) == 0 {
-		return []map[string]string{}, "Pending", nil
+		return models.Results{}, models.StatusPending, nil
 	}
 
-	if len(apiResponse) < 1 {
-		return []map[string]string{}, "", fmt.Errorf("Got an unexpected number of results: %d", len(apiResponse))
+	if len(apiResponse) > 1 {
+		return models.Results{}, models.QueryStatus{}, fmt.Errorf("Got an unexpected number of results: %d", len(apiResponse))
 	}
 
+	// osctrl reports the osquery status code of the distributed write
 	for _, result := range apiResponse {
-		return result.Rows, string(result.Status), nil
+		results, err := models.DecodeResults(result.Rows)
+		if err != nil {
+			return results, models.QueryStatus{}, fmt.Errorf("Could not parse query results: %s", err)
+		}
+		if result.Status != 0 {
+			return results, models.StatusFailed(result.Status, ""), nil
+		}
+		return results, models.StatusComplete, nil
 	}
 
 	panic("Machine results was guaranteed to have one result yet we didn't return it")
-	return []map[string]string{}, "", fmt.Errorf("FetchResults call failed: %s", err)
 }
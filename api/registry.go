@@ -0,0 +1,61 @@
+// Package api is the registry of backends goquery can talk to. Backends register
+// a Factory under the name used for the apiDriver config option, usually from an
+// init function so importing the backend package is enough to make it available.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/AbGuthrie/goquery/v2/config"
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+// Factory creates a backend. settings holds the backend's entry from the apiSettings
+// section of the config file and is nil when the config has none.
+type Factory func(cfg config.Config, settings json.RawMessage) (models.GoQueryAPI, error)
+
+var mutex sync.Mutex
+var factories = map[string]Factory{}
+
+// Register makes a backend available by name. It panics if the name is already
+// taken, as two backends fighting over a name is a programming error.
+func Register(name string, factory Factory) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if factory == nil {
+		panic("api: Register factory is nil")
+	}
+	if _, exists := factories[name]; exists {
+		panic("api: Register called twice for driver " + name)
+	}
+	factories[name] = factory
+}
+
+// Drivers returns the sorted names of all registered backends
+func Drivers() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Create builds the backend registered under driver using its settings from cfg
+func Create(driver string, cfg config.Config) (models.GoQueryAPI, error) {
+	mutex.Lock()
+	factory, ok := factories[driver]
+	mutex.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("Unknown API driver '%s', available drivers: %v", driver, Drivers())
+	}
+	return factory(cfg, cfg.APISettings[driver])
+}
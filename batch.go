@@ -0,0 +1,59 @@
+package goquery
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/commands"
+	"github.com/AbGuthrie/goquery/v2/config"
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
+)
+
+// RunCommands is the non interactive entry point. Each command is run in order through
+// the same path as the prompt, stopping at the first one that fails. A nil error means
+// every command succeeded or .exit was run.
+func RunCommands(api models.GoQueryAPI, _config config.Config, commandLines []string) error {
+	return ExecuteLines(newSession(api, _config), commandLines)
+}
+
+// RunScript runs newline separated commands read from script as RunCommands does. Blank
+// lines and lines starting with # are skipped, and errors are reported with their line number.
+func RunScript(api models.GoQueryAPI, _config config.Config, script io.Reader) error {
+	return ExecuteScript(newSession(api, _config), script)
+}
+
+// ExecuteLines runs each command in a session as RunCommands does
+func ExecuteLines(s *session.Session, commandLines []string) error {
+	for _, commandLine := range commandLines {
+		if err := Execute(s, commandLine); err != nil {
+			if err == commands.ErrExit {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// ExecuteScript runs the commands read from script in a session as RunScript does
+func ExecuteScript(s *session.Session, script io.Reader) error {
+	scanner := bufio.NewScanner(script)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := Execute(s, line); err != nil {
+			if err == commands.ErrExit {
+				return nil
+			}
+			return fmt.Errorf("line %d: %s", lineNumber, err)
+		}
+	}
+	return scanner.Err()
+}
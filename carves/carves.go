@@ -0,0 +1,79 @@
+// Package carves reassembles the archives osquery uploads when it carves files
+// and extracts the carved file from them.
+package carves
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+// zstdMagic starts archives osquery compressed with --carver_compression
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// Find returns the carve started by the distributed query named requestID
+func Find(carves []models.Carve, requestID string) (models.Carve, bool) {
+	for _, carve := range carves {
+		if carve.RequestID == requestID {
+			return carve, true
+		}
+	}
+	return models.Carve{}, false
+}
+
+// Reassemble writes a carve's archive to w, fetching its blocks in order, and
+// checks that it adds up to the size the host announced
+func Reassemble(ctx context.Context, api models.CarveAPI, carve models.Carve, w io.Writer) error {
+	var received int64
+	for block := 0; block < carve.BlockCount; block++ {
+		data, err := api.FetchCarveBlockContext(ctx, carve.ID, block)
+		if err != nil {
+			return err
+		}
+		received += int64(len(data))
+		if received > carve.Size {
+			return fmt.Errorf("The carve is larger than the %d bytes the host announced", carve.Size)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("Could not write the carve: %s", err)
+		}
+	}
+	if received != carve.Size {
+		return fmt.Errorf("The carve is %d bytes but the host announced %d", received, carve.Size)
+	}
+	return nil
+}
+
+// Extract copies the first regular file in a carve's archive to w, returning
+// its name in the archive and its size
+func Extract(archive io.Reader, w io.Writer) (string, int64, error) {
+	buffered := bufio.NewReader(archive)
+	magic, _ := buffered.Peek(len(zstdMagic))
+	if bytes.Equal(magic, zstdMagic) {
+		return "", 0, fmt.Errorf("The carve is zstd compressed, run osquery with --carver_compression=false to retrieve files")
+	}
+
+	files := tar.NewReader(buffered)
+	for {
+		header, err := files.Next()
+		if err == io.EOF {
+			return "", 0, fmt.Errorf("The carve contains no file")
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("Could not read the carve: %s", err)
+		}
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		size, err := io.Copy(w, files)
+		if err != nil {
+			return "", 0, fmt.Errorf("Could not extract %s from the carve: %s", header.Name, err)
+		}
+		return header.Name, size, nil
+	}
+}
@@ -0,0 +1,123 @@
+// Command goquery starts an interactive goquery shell against the backend
+// selected by the apiDriver config option or the --driver flag. With -c or
+// --script it instead runs the given commands and exits, with a non zero
+// status if any of them fail.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2"
+	"github.com/AbGuthrie/goquery/v2/api"
+	"github.com/AbGuthrie/goquery/v2/config"
+	"github.com/AbGuthrie/goquery/v2/pager"
+	"github.com/AbGuthrie/goquery/v2/printers"
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	// Built in backends register themselves with the api package
+	_ "github.com/AbGuthrie/goquery/v2/api/mock"
+	_ "github.com/AbGuthrie/goquery/v2/api/osctrl"
+)
+
+func main() {
+	configPath := flag.String("config", "", "Path to a config file (default ~/.goquery/config.json, then /var/goquery/config.json)")
+	driver := flag.String("driver", "", fmt.Sprintf("API driver to use, overrides apiDriver from the config (%s)", strings.Join(api.Drivers(), ", ")))
+	debug := flag.Bool("debug", false, "Enable debug mode")
+	host := flag.String("host", "", "UUID of a host to connect to before running commands")
+	command := flag.String("c", "", "Run a single command non interactively and exit")
+	script := flag.String("script", "", "Run the commands in a file non interactively and exit")
+	mode := flag.String("mode", "", fmt.Sprintf("Print mode to use, overrides printMode from the config (%s)", strings.Join(printers.Names(), ", ")))
+	noPager := flag.Bool("no-pager", false, "Print results directly instead of paging those taller than the terminal")
+	noRemoteCompletion := flag.Bool("no-remote-completion", false, "Don't suggest paths on the remote host for cd and ls")
+	flag.Parse()
+
+	if *command != "" && *script != "" {
+		fmt.Fprintf(os.Stderr, "Only one of -c and --script may be provided\n")
+		os.Exit(2)
+	}
+
+	if *configPath == "" {
+		*configPath = config.DefaultPath()
+	}
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't load user config because of error: %s\n", err)
+		fmt.Fprintln(os.Stderr, "Using defaults")
+		cfg = config.Config{
+			PrintMode: config.PrintPretty,
+			Aliases:   map[string]config.Alias{},
+		}
+	}
+
+	if *debug {
+		cfg.DebugEnabled = true
+	}
+	if *driver != "" {
+		cfg.APIDriver = *driver
+	}
+	if *mode != "" {
+		if _, err := printers.Get(*mode); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(2)
+		}
+		cfg.PrintMode = config.PrintModeEnum(*mode)
+	}
+	if *noPager {
+		cfg.Pager = pager.Never
+	}
+	if *noRemoteCompletion {
+		cfg.DisableRemoteCompletion = true
+	}
+	if cfg.APIDriver == "" {
+		fmt.Fprintf(os.Stderr, "No API driver selected, set apiDriver in the config or pass --driver (%s)\n", strings.Join(api.Drivers(), ", "))
+		os.Exit(2)
+	}
+
+	backend, err := api.Create(cfg.APIDriver, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered an error starting API: %s\n", err)
+		os.Exit(1)
+	}
+
+	// Print errors/warnings with provided aliases, and print state of debug flags
+	cfg.Validate()
+	s := session.New(backend, cfg)
+
+	// Connecting is just the first command so it shares the same error handling
+	commandLines := []string{}
+	if *host != "" {
+		commandLines = append(commandLines, ".connect "+*host)
+	}
+
+	switch {
+	case *command != "":
+		err = goquery.ExecuteLines(s, append(commandLines, *command))
+	case *script != "":
+		err = runScript(s, commandLines, *script)
+	default:
+		err = goquery.ExecuteLines(s, commandLines)
+		if err == nil {
+			goquery.RunSession(s)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+func runScript(s *session.Session, commandLines []string, scriptPath string) error {
+	scriptFile, err := os.Open(scriptPath)
+	if err != nil {
+		return err
+	}
+	defer scriptFile.Close()
+
+	if err := goquery.ExecuteLines(s, commandLines); err != nil {
+		return err
+	}
+	return goquery.ExecuteScript(s, scriptFile)
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+func cancelQuery(ctx context.Context, session *session.Session, cmdline string) error {
+	canceller, ok := models.Unwrap(session.API).(models.QueryCanceller)
+	if !ok {
+		return fmt.Errorf("The current backend does not support cancelling queries")
+	}
+
+	args := strings.Split(cmdline, " ") // Separate command and arguments
+	if len(args) != 2 || args[1] == "" {
+		return fmt.Errorf("A query name to cancel must be provided")
+	}
+	queryName := args[1]
+
+	if err := canceller.CancelQueryContext(ctx, queryName); err != nil {
+		return err
+	}
+
+	// Forget the query so it is no longer offered to .resume or listed in .jobs
+	session.Jobs.Remove(queryName)
+	for _, host := range session.Hosts.GetCurrentHosts() {
+		if err := session.Hosts.RemoveQueryFromHost(host.UUID, queryName); err == nil {
+			break
+		}
+	}
+	session.Printf("Cancelled query %s\n", queryName)
+
+	return nil
+}
+
+func cancelQueryHelp() string {
+	return "Cancel a scheduled query before it is delivered to the host"
+}
+
+func cancelQuerySuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	return resumeSuggest(session, cmdline)
+}
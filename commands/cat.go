@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+// maxCatSize limits cat to files small enough to print, larger ones can be saved with .get
+const maxCatSize = 1 << 20
+
+func cat(ctx context.Context, session *session.Session, cmdline string) error {
+	host, err := session.Hosts.GetCurrentHost()
+	if err != nil {
+		return fmt.Errorf("No host is currently connected: %s", err)
+	}
+	args, err := splitArguments(cmdline)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("A single file must be provided, quote paths containing spaces")
+	}
+	api, err := carveAPI(session)
+	if err != nil {
+		return err
+	}
+
+	file, err := statRemoteFile(ctx, session, host, args[1])
+	if err != nil {
+		return err
+	}
+	if file.size > maxCatSize {
+		return fmt.Errorf("%s is %s, use .get to retrieve files larger than %s", file.path, humanSize(file.size),
+			humanSize(maxCatSize))
+	}
+
+	// The file can grow between the stat and the carve, so the limit is enforced
+	// on what is actually extracted too
+	contents := limitedBuffer{limit: maxCatSize}
+	if _, err := carveFile(ctx, session, api, host, file, &contents); err != nil {
+		if contents.exceeded {
+			return fmt.Errorf("%s is larger than %s, use .get to retrieve it", file.path, humanSize(maxCatSize))
+		}
+		return err
+	}
+	if bytes.IndexByte(contents.Bytes(), 0) != -1 || !utf8.Valid(contents.Bytes()) {
+		return fmt.Errorf("%s is not a text file, use .get to retrieve it", file.path)
+	}
+
+	// Files on a compromised host can hold escape sequences that rewrite the
+	// screen or set the terminal's title or clipboard, so they are shown escaped
+	text, escaped := escapeControls(contents.String())
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	session.Printf("%s", text)
+	if escaped > 0 {
+		fmt.Fprintf(session.Err, "Escaped %d control character(s), use .get to retrieve the file as it is\n", escaped)
+	}
+	return nil
+}
+
+// limitedBuffer is a buffer that refuses writes that would take it past limit bytes
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (buffer *limitedBuffer) Write(data []byte) (int, error) {
+	if buffer.Len()+len(data) > buffer.limit {
+		buffer.exceeded = true
+		return 0, fmt.Errorf("More than %d bytes written", buffer.limit)
+	}
+	return buffer.Buffer.Write(data)
+}
+
+// escapeControls replaces C0 and C1 control characters other than newlines and
+// tabs with Go escapes like \x1b, returning how many were replaced. Windows line
+// endings are printed as newlines.
+func escapeControls(text string) (string, int) {
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	escaped := 0
+	builder := strings.Builder{}
+	for _, char := range text {
+		if char == '\n' || char == '\t' || !unicode.IsControl(char) {
+			builder.WriteRune(char)
+			continue
+		}
+		escaped++
+		if char < 0x80 {
+			fmt.Fprintf(&builder, "\\x%02x", char)
+		} else {
+			fmt.Fprintf(&builder, "\\u%04x", char)
+		}
+	}
+	return builder.String(), escaped
+}
+
+func catHelp() string {
+	return "Print a small text file from the current host, retrieved by carving it"
+}
+
+func catSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	return remotePathSuggest(session, cmdline, false)
+}
@@ -0,0 +1,42 @@
+package commands
+
+import "testing"
+
+func TestLimitedBuffer(t *testing.T) {
+	buffer := limitedBuffer{limit: 5}
+	if _, err := buffer.Write([]byte("abc")); err != nil {
+		t.Fatalf("writing within the limit failed: %s", err)
+	}
+	if _, err := buffer.Write([]byte("de")); err != nil {
+		t.Fatalf("writing up to the limit failed: %s", err)
+	}
+	if buffer.exceeded {
+		t.Errorf("exceeded after writing exactly the limit")
+	}
+	if _, err := buffer.Write([]byte("f")); err == nil {
+		t.Errorf("writing past the limit succeeded")
+	}
+	if !buffer.exceeded || buffer.String() != "abcde" {
+		t.Errorf("got %q, exceeded %t, want \"abcde\" and exceeded", buffer.String(), buffer.exceeded)
+	}
+}
+
+func TestEscapeControls(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		escaped int
+	}{
+		{"plain\ttext\n", "plain\ttext\n", 0},
+		{"line\r\nending", "line\nending", 0},
+		{"\x1b]0;title\x07", "\\x1b]0;title\\x07", 2},
+		{"\u009b2J", "\\u009b2J", 1},
+		{"日本語", "日本語", 0},
+	}
+	for _, test := range tests {
+		got, escaped := escapeControls(test.text)
+		if got != test.want || escaped != test.escaped {
+			t.Errorf("escapeControls(%q) = %q, %d, want %q, %d", test.text, got, escaped, test.want, test.escaped)
+		}
+	}
+}
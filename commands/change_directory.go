@@ -1,22 +1,20 @@
 package commands
 
 import (
+	"context"
 	"fmt"
-	"path/filepath"
 	"strings"
 
-	"github.com/AbGuthrie/goquery/v2/config"
-	"github.com/AbGuthrie/goquery/v2/hosts"
-	"github.com/AbGuthrie/goquery/v2/models"
-	"github.com/AbGuthrie/goquery/v2/utils"
+	"github.com/AbGuthrie/goquery/v2/session"
+	"github.com/AbGuthrie/goquery/v2/sqlquote"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-var verificationTemplate = "select * from file where path = '%s' and type = 'directory'"
+var verificationTemplate = "select * from file where path = %s and type = 'directory'"
 
-func changeDirectory(api models.GoQueryAPI, config *config.Config, cmdline string) error {
-	host, err := hosts.GetCurrentHost()
+func changeDirectory(ctx context.Context, session *session.Session, cmdline string) error {
+	host, err := session.Hosts.GetCurrentHost()
 	if err != nil {
 		return fmt.Errorf("No host is currently connected: %s", err)
 	}
@@ -33,36 +31,41 @@ func changeDirectory(api models.GoQueryAPI, config *config.Config, cmdline strin
 	if len(requestedDirectory) == 0 {
 		return fmt.Errorf("Directory requested is invalid")
 	}
-
-	// The change isn't absolute so we need the current directory
-	if requestedDirectory[0] != '/' {
-		requestedDirectory = host.CurrentDirectory + requestedDirectory
+	// Quoted directories, such as those filled in from variables, are unquoted
+	if strings.HasPrefix(requestedDirectory, "'") || strings.HasPrefix(requestedDirectory, `"`) {
+		quotedArgs, err := splitArguments(requestedDirectory)
+		if err != nil {
+			return err
+		}
+		if len(quotedArgs) != 1 {
+			return fmt.Errorf("A single directory must be provided")
+		}
+		requestedDirectory = quotedArgs[0]
 	}
-	requestedDirectory = filepath.Clean(requestedDirectory)
 
-	// All directory changes must end with a forward slash
-	if requestedDirectory[len(requestedDirectory)-1] != '/' {
-		requestedDirectory += "/"
-	}
+	// Relative changes are resolved against the current directory, and all
+	// directories end with a separator, written the way the host's platform does
+	paths := host.Paths()
+	requestedDirectory = paths.Directory(paths.Resolve(host.CurrentDirectory, requestedDirectory))
 
-	verificationQuery := fmt.Sprintf(verificationTemplate, requestedDirectory)
-	results, err := utils.ScheduleQueryAndWait(api, host.UUID, verificationQuery)
+	verificationQuery := fmt.Sprintf(verificationTemplate, sqlquote.String(requestedDirectory))
+	results, err := session.ScheduleQueryAndWait(ctx, host.UUID, verificationQuery)
 
 	if err != nil {
 		return err
 	}
 
-	if len(results) != 1 {
+	if results.Len() != 1 {
 		return fmt.Errorf("No such directory")
 	}
 
-	return hosts.SetCurrentHostDirectory(requestedDirectory)
+	return session.Hosts.SetCurrentHostDirectory(requestedDirectory)
 }
 
 func changeDirectoryHelp() string {
 	return "Change directories on a remote host"
 }
 
-func changeDirectorySuggest(cmdline string) []prompt.Suggest {
-	return []prompt.Suggest{}
+func changeDirectorySuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	return remotePathSuggest(session, cmdline, true)
 }
@@ -1,18 +1,18 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 
-	"github.com/AbGuthrie/goquery/v2/config"
-	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
 	prompt "github.com/c-bata/go-prompt"
 )
 
-func clear(api models.GoQueryAPI, config *config.Config, cmdline string) error {
+func clear(ctx context.Context, session *session.Session, cmdline string) error {
 	args := strings.Split(cmdline, " ") // Separate command and arguments
 	if len(args) > 1 {
 		return fmt.Errorf("This command takes no parameters")
@@ -35,6 +35,6 @@ func clearHelp() string {
 	return "Clear the terminal screen"
 }
 
-func clearSuggest(cmdline string) []prompt.Suggest {
+func clearSuggest(session *session.Session, cmdline string) []prompt.Suggest {
 	return []prompt.Suggest{}
 }
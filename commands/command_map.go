@@ -1,24 +1,48 @@
 package commands
 
 import (
+	"context"
 	"errors"
 
 	"github.com/AbGuthrie/goquery/v2/config"
 	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
 	prompt "github.com/c-bata/go-prompt"
 )
 
 // GoQueryCommand defines the functions required to add a new command to goquery
+// Execute receives a context that is cancelled when the user interrupts the
+// command or the configured command timeout passes, and the session to run in
 type GoQueryCommand struct {
-	Execute     func(models.GoQueryAPI, *config.Config, string) error
+	Execute     func(context.Context, *session.Session, string) error
 	Help        func() string
-	Suggestions func(string) []prompt.Suggest
+	Suggestions func(*session.Session, string) []prompt.Suggest
+}
+
+// LegacyExecute is the Execute signature used before commands received a session
+type LegacyExecute func(context.Context, models.GoQueryContextAPI, *config.Config, string) error
+
+// Legacy adapts a command written against the LegacyExecute signature, and
+// suggestions that don't take a session, to a GoQueryCommand
+func Legacy(execute LegacyExecute, help func() string, suggestions func(string) []prompt.Suggest) GoQueryCommand {
+	return GoQueryCommand{
+		Execute: func(ctx context.Context, session *session.Session, cmdline string) error {
+			return execute(ctx, session.API, session.Config, cmdline)
+		},
+		Help: help,
+		Suggestions: func(session *session.Session, cmdline string) []prompt.Suggest {
+			return suggestions(cmdline)
+		},
+	}
 }
 
 // CommandMap is the mapping from command line string to GoQueryCommand
 // structure
 var CommandMap map[string]GoQueryCommand
 
+// ErrExit is returned by .exit to ask the caller to end the session
+var ErrExit = errors.New("exit requested")
+
 // Errors
 var errArgumentError error
 var errRuntimeError error
@@ -26,19 +50,31 @@ var errRuntimeError error
 func init() {
 	CommandMap = map[string]GoQueryCommand{
 		".alias":      GoQueryCommand{alias, aliasHelp, aliasSuggest},
+		".cancel":     GoQueryCommand{cancelQuery, cancelQueryHelp, cancelQuerySuggest},
+		".compare":    GoQueryCommand{compareHosts, compareHostsHelp, compareHostsSuggest},
 		".connect":    GoQueryCommand{connect, connectHelp, connectSuggest},
 		".clear":      GoQueryCommand{clear, clearHelp, clearSuggest},
+		".diff":       GoQueryCommand{diffQueries, diffQueriesHelp, diffQueriesSuggest},
 		".disconnect": GoQueryCommand{disconnect, disconnectHelp, disconnectSuggest},
 		".exit":       GoQueryCommand{exit, exitHelp, exitSuggest},
+		".group":      GoQueryCommand{group, groupHelp, groupSuggest},
+		".get":        GoQueryCommand{get, getHelp, getSuggest},
 		".help":       GoQueryCommand{help, helpHelp, helpSuggest},
 		".history":    GoQueryCommand{history, historyHelp, historySuggest},
 		".hosts":      GoQueryCommand{printHosts, printHostsHelp, printHostsSuggest},
+		".jobs":       GoQueryCommand{listJobs, listJobsHelp, listJobsSuggest},
+		".let":        GoQueryCommand{let, letHelp, letSuggest},
+		".local":      GoQueryCommand{local, localHelp, localSuggest},
 		".mode":       GoQueryCommand{changeMode, changeModeHelp, changeModeSuggest},
 		".query":      GoQueryCommand{query, queryHelp, querySuggest},
+		".queryall":   GoQueryCommand{queryAll, queryAllHelp, queryAllSuggest},
 		".resume":     GoQueryCommand{resume, resumeHelp, resumeSuggest},
 		".schedule":   GoQueryCommand{schedule, scheduleHelp, scheduleSuggest},
+		".tee":        GoQueryCommand{tee, teeHelp, teeSuggest},
+		".watch":      GoQueryCommand{watch, watchHelp, watchSuggest},
 		"ls":          GoQueryCommand{listDirectory, listDirectoryHelp, listDirectorySuggest},
 		"cd":          GoQueryCommand{changeDirectory, changeDirectoryHelp, changeDirectorySuggest},
+		"cat":         GoQueryCommand{cat, catHelp, catSuggest},
 	}
 	errArgumentError = errors.New("The arguments provided were incorrect for the command")
 	errRuntimeError = errors.New("There was a problem executing the command")
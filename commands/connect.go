@@ -1,36 +1,56 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
-	"github.com/AbGuthrie/goquery/v2/config"
-	"github.com/AbGuthrie/goquery/v2/hosts"
-	"github.com/AbGuthrie/goquery/v2/models"
-	"github.com/AbGuthrie/goquery/v2/utils"
+	"github.com/AbGuthrie/goquery/v2/session"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-func connect(api models.GoQueryAPI, config *config.Config, cmdline string) error {
+func connect(ctx context.Context, session *session.Session, cmdline string) error {
 	args := strings.Split(cmdline, " ") // Separate command and arguments
 	if len(args) == 1 {
 		return fmt.Errorf("Host UUID required")
 	}
-	uuid := args[1]
-	host, err := api.CheckHost(uuid)
+
+	// Connecting to a group connects to each of its members in turn
+	if members, ok := session.Hosts.GetGroups()[args[1]]; ok {
+		if len(members) == 0 {
+			return fmt.Errorf("Group '%s' has no members", args[1])
+		}
+		failed := 0
+		for _, uuid := range members {
+			if err := connectHost(ctx, session, uuid); err != nil {
+				fmt.Fprintf(session.Err, "Could not connect to %s: %s\n", uuid, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("Failed to connect to %d of %d host(s) in group '%s'", failed, len(members), args[1])
+		}
+		return nil
+	}
+
+	return connectHost(ctx, session, args[1])
+}
+
+func connectHost(ctx context.Context, session *session.Session, uuid string) error {
+	host, err := session.API.CheckHostContext(ctx, uuid)
 	if err != nil {
 		return err
 	}
 
 	// All is good, update hosts state
-	if err := hosts.Register(host); err != nil {
+	if err := session.Hosts.Register(host); err != nil {
 		return fmt.Errorf("Error connecting to host: %s", err)
 	}
-	fmt.Printf("Verified Host(%s) Exists.\n", uuid)
+	fmt.Fprintf(session.Err, "Verified Host(%s) Exists.\n", uuid)
 
-	results, err := utils.ScheduleQueryAndWait(
-		api,
+	results, err := session.ScheduleQueryAndWait(
+		ctx,
 		host.UUID,
 		"select name from osquery_registry where registry = 'table' and active = 1",
 	)
@@ -40,25 +60,23 @@ func connect(api models.GoQueryAPI, config *config.Config, cmdline string) error
 	}
 
 	tables := make([]string, 0)
-	for _, row := range results {
+	for _, row := range results.Rows {
 		// Probably unneeded guard against bad osquery/api data
 		if table, ok := row["name"]; ok {
 			tables = append(tables, table)
 		}
 	}
-	hosts.SetHostTables(host.UUID, tables)
-
-	return nil
+	return session.Hosts.SetHostTables(host.UUID, tables)
 }
 
 func connectHelp() string {
-	return "Connect to a host with UUID"
+	return "Connect to a host with UUID, or every host in a group"
 }
 
-func connectSuggest(cmdline string) []prompt.Suggest {
-	prompts := []prompt.Suggest{}
-	for _, host := range hosts.GetCurrentHosts() {
-		prompts = append(prompts, prompt.Suggest{host.UUID, host.ComputerName})
+func connectSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	prompts := groupNameSuggest(session)
+	for _, host := range session.Hosts.GetCurrentHosts() {
+		prompts = append(prompts, prompt.Suggest{Text: host.UUID, Description: host.ComputerName})
 	}
 	return prompts
 }
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/AbGuthrie/goquery/v2/diff"
+	"github.com/AbGuthrie/goquery/v2/hosts"
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+func diffQueries(ctx context.Context, session *session.Session, cmdline string) error {
+	keys, args, err := parseKeyFlag(strings.Fields(cmdline)[1:])
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("Usage: .diff [--key COLUMN,...] QUERY_NAME QUERY_NAME")
+	}
+
+	before, err := fetchCompleteResults(ctx, session, args[0])
+	if err != nil {
+		return err
+	}
+	after, err := fetchCompleteResults(ctx, session, args[1])
+	if err != nil {
+		return err
+	}
+	return printDiff(session, before, after, keys, args[0], args[1])
+}
+
+func compareHosts(ctx context.Context, session *session.Session, cmdline string) error {
+	keys, args, err := parseKeyFlag(strings.Fields(cmdline)[1:])
+	if err != nil {
+		return err
+	}
+	if len(args) < 3 {
+		return fmt.Errorf("Usage: .compare [--key COLUMN,...] UUID UUID QUERY")
+	}
+
+	targets := make([]hosts.Host, 2)
+	for i, selector := range args[:2] {
+		selected, err := session.Hosts.Select(selector)
+		if err != nil {
+			return err
+		}
+		if len(selected) != 1 {
+			return fmt.Errorf("%s matches %d connected hosts, pick one by UUID", selector, len(selected))
+		}
+		targets[i] = selected[0]
+	}
+	// The query is everything after the command, flags and hosts
+	query := trimFields(cmdline, len(strings.Fields(cmdline))-len(args)+2)
+
+	hostResults := fanOutQuery(ctx, session, targets, query, defaultHostTimeout)
+	for _, hostResult := range hostResults {
+		if hostResult.err != nil {
+			return fmt.Errorf("Query failed on %s: %s", hostResult.host.ComputerName, hostResult.err)
+		}
+	}
+	return printDiff(session, hostResults[0].results, hostResults[1].results, keys,
+		targets[0].ComputerName, targets[1].ComputerName)
+}
+
+// parseKeyFlag removes a leading --key COLUMN,... from args
+func parseKeyFlag(args []string) ([]string, []string, error) {
+	if len(args) == 0 || args[0] != "--key" {
+		return nil, args, nil
+	}
+	if len(args) == 1 {
+		return nil, nil, fmt.Errorf("--key flag requires a comma separated list of columns")
+	}
+	return strings.Split(args[1], ","), args[2:], nil
+}
+
+// trimFields removes the first count whitespace separated fields from text
+func trimFields(text string, count int) string {
+	text = strings.TrimSpace(text)
+	for i := 0; i < count; i++ {
+		end := strings.IndexFunc(text, unicode.IsSpace)
+		if end == -1 {
+			return ""
+		}
+		text = strings.TrimSpace(text[end:])
+	}
+	return text
+}
+
+func fetchCompleteResults(ctx context.Context, session *session.Session, queryName string) (models.Results, error) {
+	results, status, err := session.FetchResults(ctx, queryName)
+	if err != nil {
+		return results, err
+	}
+	if status.Pending() {
+		return results, fmt.Errorf("Query %s has not finished yet", queryName)
+	}
+	return results, status.Err()
+}
+
+func printDiff(session *session.Session, before, after models.Results, keys []string, beforeName, afterName string) error {
+	differences, summary, err := diff.Results(before, after, keys)
+	if err != nil {
+		return err
+	}
+	session.PrintResults(differences)
+	session.Printf("%s -> %s: %s\n", beforeName, afterName, summary)
+	return nil
+}
+
+func diffQueriesHelp() string {
+	return "Show the rows added, removed and changed between the results of two queries, " +
+		"matching rows by --key COLUMN,... or by every column"
+}
+
+func compareHostsHelp() string {
+	return "Run a query on two connected hosts and show the rows added, removed and changed on the second, " +
+		"matching rows by --key COLUMN,... or by every column"
+}
+
+func diffQueriesSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	args := strings.Split(cmdline, " ")
+	prompts := []prompt.Suggest{}
+	if len(args) == 2 {
+		prompts = append(prompts, prompt.Suggest{Text: "--key", Description: "Columns identifying a row, such as pid"})
+	}
+	if len(args) >= 2 && args[len(args)-2] == "--key" {
+		return []prompt.Suggest{}
+	}
+	for _, host := range session.Hosts.GetCurrentHosts() {
+		for _, query := range host.QueryHistory {
+			prompts = append(prompts, prompt.Suggest{Text: query.Name, Description: host.ComputerName + ": " + query.SQL})
+		}
+	}
+	return prompts
+}
+
+func compareHostsSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	args := strings.Split(cmdline, " ")
+	_, hostArgs, _ := parseKeyFlag(args[1:])
+	prompts := []prompt.Suggest{}
+	if len(args) == 2 {
+		prompts = append(prompts, prompt.Suggest{Text: "--key", Description: "Columns identifying a row, such as pid"})
+	}
+	if len(args) >= 2 && args[len(args)-2] == "--key" {
+		return []prompt.Suggest{}
+	}
+	if len(hostArgs) > 2 {
+		return querySuggest(session, cmdline)
+	}
+	for _, host := range session.Hosts.GetCurrentHosts() {
+		prompts = append(prompts, prompt.Suggest{Text: host.UUID, Description: host.ComputerName})
+	}
+	return prompts
+}
@@ -1,27 +1,26 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
-	"github.com/AbGuthrie/goquery/v2/config"
-	"github.com/AbGuthrie/goquery/v2/hosts"
-	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-func disconnect(api models.GoQueryAPI, config *config.Config, cmdline string) error {
+func disconnect(ctx context.Context, session *session.Session, cmdline string) error {
 	args := strings.Split(cmdline, " ") // Separate command and arguments
 	if len(args) == 1 {
 		return fmt.Errorf("Host UUID required")
 	}
 	uuid := args[1]
 
-	if err := hosts.Disconnect(uuid); err != nil {
+	if err := session.Hosts.Disconnect(uuid); err != nil {
 		return fmt.Errorf("Error disconnecting from host: %s", err)
 	}
-	fmt.Printf("Disconnected from '%s'\n", uuid)
+	session.Printf("Disconnected from '%s'\n", uuid)
 
 	return nil
 }
@@ -30,10 +29,10 @@ func disconnectHelp() string {
 	return "Disconnect from a host with UUID"
 }
 
-func disconnectSuggest(cmdline string) []prompt.Suggest {
+func disconnectSuggest(session *session.Session, cmdline string) []prompt.Suggest {
 	prompts := []prompt.Suggest{}
-	for _, host := range hosts.GetCurrentHosts() {
-		prompts = append(prompts, prompt.Suggest{host.UUID, host.ComputerName})
+	for _, host := range session.Hosts.GetCurrentHosts() {
+		prompts = append(prompts, prompt.Suggest{Text: host.UUID, Description: host.ComputerName})
 	}
 	return prompts
 }
@@ -1,24 +1,21 @@
 package commands
 
 import (
-	"fmt"
-	"os"
+	"context"
 
-	"github.com/AbGuthrie/goquery/v2/config"
-	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
 	prompt "github.com/c-bata/go-prompt"
 )
 
-func exit(api models.GoQueryAPI, config *config.Config, cmdline string) error {
-	fmt.Printf("Goodbye!\n")
-	os.Exit(0)
-	return errRuntimeError
+func exit(ctx context.Context, session *session.Session, cmdline string) error {
+	session.Printf("Goodbye!\n")
+	return ErrExit
 }
 
 func exitHelp() string {
 	return "Exit goquery"
 }
 
-func exitSuggest(cmdline string) []prompt.Suggest {
+func exitSuggest(session *session.Session, cmdline string) []prompt.Suggest {
 	return []prompt.Suggest{}
 }
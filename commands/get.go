@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AbGuthrie/goquery/v2/carves"
+	"github.com/AbGuthrie/goquery/v2/hosts"
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/printers"
+	"github.com/AbGuthrie/goquery/v2/session"
+	"github.com/AbGuthrie/goquery/v2/sqlquote"
+	"github.com/AbGuthrie/goquery/v2/utils"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+const remoteFileTemplate = "select f.path, f.type, f.size, h.sha256 from file f left join hash h on h.path = f.path " +
+	"where f.path in %s"
+
+const carveTemplate = "select * from carves where path = %s and carve = 1"
+
+// remoteFile is a file to carve as the file and hash tables describe it
+type remoteFile struct {
+	path   string
+	size   int64
+	sha256 string
+}
+
+func get(ctx context.Context, session *session.Session, cmdline string) error {
+	host, err := session.Hosts.GetCurrentHost()
+	if err != nil {
+		return fmt.Errorf("No host is currently connected: %s", err)
+	}
+	args, err := splitArguments(cmdline)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("Usage: .get <remote path> [local destination]")
+	}
+	api, err := carveAPI(session)
+	if err != nil {
+		return err
+	}
+
+	file, err := statRemoteFile(ctx, session, host, args[1])
+	if err != nil {
+		return err
+	}
+	paths := host.Paths()
+	destination := file.path[paths.LastSeparator(file.path)+1:]
+	if len(args) == 3 {
+		destination = utils.ExpandHome(args[2])
+		if info, err := os.Stat(destination); err == nil && info.IsDir() {
+			destination = filepath.Join(destination, file.path[paths.LastSeparator(file.path)+1:])
+		}
+	}
+	if _, err := os.Lstat(destination); err == nil {
+		return fmt.Errorf("%s already exists", destination)
+	}
+
+	// Write next to the destination and only move into place once verified, so a
+	// failed or mismatched carve leaves nothing behind
+	temp, err := os.CreateTemp(filepath.Dir(destination), ".goquery-get-*")
+	if err != nil {
+		return fmt.Errorf("Could not create %s: %s", destination, err)
+	}
+	defer os.Remove(temp.Name())
+	if err := temp.Chmod(0644); err != nil {
+		temp.Close()
+		return fmt.Errorf("Could not create %s: %s", destination, err)
+	}
+	size, err := carveFile(ctx, session, api, host, file, temp)
+	if closeErr := temp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("Could not write %s: %s", destination, closeErr)
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), destination); err != nil {
+		return fmt.Errorf("Could not write %s: %s", destination, err)
+	}
+
+	session.Printf("Saved %s (%d bytes) to %s\n", file.path, size, destination)
+	return nil
+}
+
+// carveAPI returns the session's backend if it supports retrieving files
+func carveAPI(session *session.Session) (models.CarveAPI, error) {
+	api, ok := models.Unwrap(session.API).(models.CarveAPI)
+	if !ok {
+		return nil, fmt.Errorf("The current backend does not support retrieving files")
+	}
+	return api, nil
+}
+
+// statRemoteFile resolves target against the current directory and looks up the
+// regular file it names, along with its hash to verify the carve against
+func statRemoteFile(ctx context.Context, session *session.Session, host hosts.Host, target string) (remoteFile, error) {
+	paths := host.Paths()
+	filePath := paths.Resolve(host.CurrentDirectory, target)
+	// Directories may be stored with a trailing separator, look them up either way
+	// to tell the user the path is a directory rather than missing
+	results, err := session.ScheduleQueryAndWait(ctx, host.UUID,
+		fmt.Sprintf(remoteFileTemplate, sqlquote.List(filePath, paths.Directory(filePath))))
+	if err != nil {
+		return remoteFile{}, err
+	}
+	if results.Len() == 0 {
+		return remoteFile{}, fmt.Errorf("No such file: %s", filePath)
+	}
+	row := results.Rows[0]
+	switch row["type"] {
+	case "regular":
+	case "directory":
+		return remoteFile{}, fmt.Errorf("%s is a directory", filePath)
+	default:
+		return remoteFile{}, fmt.Errorf("%s is not a regular file", filePath)
+	}
+	return remoteFile{path: row["path"], size: numberValue(row["size"]), sha256: strings.ToLower(row["sha256"])}, nil
+}
+
+// carveFile carves file from the host, waiting for the upload to finish, and
+// writes its contents to w after checking them against the file's SHA-256
+func carveFile(ctx context.Context, session *session.Session, api models.CarveAPI, host hosts.Host, file remoteFile,
+	w io.Writer) (int64, error) {
+	queryName, err := session.ScheduleQuery(ctx, host.UUID, fmt.Sprintf(carveTemplate, sqlquote.String(file.path)))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := session.WaitForResults(ctx, queryName, func() {
+		fmt.Fprintf(session.Err, ".")
+	}); err != nil {
+		fmt.Fprintf(session.Err, "\n")
+		return 0, fmt.Errorf("Could not start carving %s: %s", file.path, err)
+	}
+	fmt.Fprintf(session.Err, "\n")
+
+	// The host uploads the carve after answering the query, so it can take a
+	// while to appear and longer to finish
+	showProgress := printers.TerminalWidth(session.Err) > 0
+	var carve models.Carve
+	for {
+		list, err := api.ListCarvesContext(ctx, host.UUID)
+		if err != nil {
+			return 0, err
+		}
+		if found, ok := carves.Find(list, queryName); ok {
+			carve = found
+			if showProgress {
+				fmt.Fprintf(session.Err, "\rReceived %d of %d blocks", carve.BlocksReceived, carve.BlockCount)
+			}
+			if carve.Complete() {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if showProgress {
+				fmt.Fprintf(session.Err, "\n")
+			}
+			return 0, fmt.Errorf("Waiting Cancelled: %s", ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+	if showProgress {
+		fmt.Fprintf(session.Err, "\n")
+	}
+
+	// Reassemble the whole archive first so it can be checked before extracting
+	archive, err := os.CreateTemp("", "goquery-carve-*")
+	if err != nil {
+		return 0, fmt.Errorf("Could not store the carve: %s", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+	if err := carves.Reassemble(ctx, api, carve, archive); err != nil {
+		return 0, err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("Could not read the carve: %s", err)
+	}
+
+	hash := sha256.New()
+	_, size, err := carves.Extract(archive, io.MultiWriter(w, hash))
+	if err != nil {
+		return 0, err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if file.sha256 == "" {
+		fmt.Fprintf(session.Err, "Warning: the host reported no SHA-256 for %s, it could not be verified\n", file.path)
+	} else if sum != file.sha256 {
+		return 0, fmt.Errorf("The carve of %s does not match the file, its SHA-256 is %s but the host reported %s",
+			file.path, sum, file.sha256)
+	}
+	return size, nil
+}
+
+func getHelp() string {
+	return "Retrieve a file from the current host by carving it, saving it to the local destination " +
+		"or a file of the same name in the working directory"
+}
+
+func getSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	if len(strings.Split(cmdline, " ")) > 2 {
+		return []prompt.Suggest{}
+	}
+	return remotePathSuggest(session, cmdline, false)
+}
@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/hosts"
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+func printGroups(session *session.Session) {
+	connectedNames := map[string]string{}
+	for _, host := range session.Hosts.GetCurrentHosts() {
+		connectedNames[host.UUID] = host.ComputerName
+	}
+
+	groups := session.Hosts.GetGroups()
+	groupRows := make([]map[string]string, 0)
+	for _, name := range session.Hosts.GroupNames() {
+		members := make([]string, 0)
+		connected := 0
+		for _, uuid := range groups[name] {
+			if computerName, ok := connectedNames[uuid]; ok {
+				members = append(members, computerName)
+				connected++
+			} else {
+				members = append(members, uuid)
+			}
+		}
+		groupRows = append(groupRows, map[string]string{
+			"group":     name,
+			"connected": fmt.Sprintf("%d/%d", connected, len(groups[name])),
+			"members":   strings.Join(members, ", "),
+		})
+	}
+
+	session.PrintResults(models.Results{
+		Columns: []string{"group", "connected", "members"},
+		Rows:    groupRows,
+	})
+}
+
+func group(ctx context.Context, session *session.Session, cmdline string) error {
+	args := strings.Fields(cmdline)
+
+	// If no args provided, print current state of groups
+	if len(args) == 1 {
+		printGroups(session)
+		return nil
+	}
+	if len(args) < 3 {
+		return fmt.Errorf("%s requires a group name", args[1])
+	}
+	name := args[2]
+
+	switch args[1] {
+	case "--create":
+		if err := session.Hosts.CreateGroup(name); err != nil {
+			return err
+		}
+		session.Printf("Created group '%s'\n", name)
+	case "--delete":
+		if err := session.Hosts.DeleteGroup(name); err != nil {
+			return err
+		}
+		session.Printf("Deleted group '%s'\n", name)
+	case "--add":
+		if len(args) < 4 {
+			return fmt.Errorf("--add requires a group name and host selectors or UUIDs")
+		}
+		uuids, err := resolveGroupMembers(session, args[3:])
+		if err != nil {
+			return err
+		}
+		if err := session.Hosts.AddToGroup(name, uuids...); err != nil {
+			return err
+		}
+		session.Printf("Added %d host(s) to group '%s'\n", len(uuids), name)
+	case "--remove":
+		if len(args) != 4 {
+			return fmt.Errorf("--remove requires a group name and a host UUID")
+		}
+		if err := session.Hosts.RemoveFromGroup(name, args[3]); err != nil {
+			return err
+		}
+		session.Printf("Removed %s from group '%s'\n", args[3], name)
+	default:
+		return fmt.Errorf(".group must be called with one of '--create', '--delete', '--add' or '--remove'")
+	}
+
+	saveHostGroups(session)
+	return nil
+}
+
+// resolveGroupMembers expands selectors against the connected hosts. Arguments that
+// match nothing and are not selector syntax are kept as raw UUIDs so groups can
+// contain hosts that aren't connected yet.
+func resolveGroupMembers(session *session.Session, selectors []string) ([]string, error) {
+	uuids := make([]string, 0)
+	for _, selector := range selectors {
+		selected, err := session.Hosts.Select(selector)
+		if err == nil {
+			for _, host := range selected {
+				uuids = append(uuids, host.UUID)
+			}
+			continue
+		}
+		if strings.ContainsAny(selector, ",=*?[") {
+			return uuids, err
+		}
+		uuids = append(uuids, selector)
+	}
+	return uuids, nil
+}
+
+// saveHostGroups copies the session's groups into the config and writes them to
+// the config file when there is one
+func saveHostGroups(session *session.Session) {
+	session.Config.HostGroups = session.Hosts.GetGroups()
+	if err := session.Config.UpdateFile("hostGroups", session.Config.HostGroups); err != nil {
+		fmt.Fprintf(session.Err, "Group changes will only last for this session: %s\n", err)
+	}
+}
+
+func groupHelp() string {
+	return "List host groups or manage them with --create, --delete, --add NAME SELECTOR and --remove NAME UUID"
+}
+
+func groupSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	args := strings.Split(cmdline, " ")
+	switch {
+	case len(args) == 2:
+		return []prompt.Suggest{
+			{Text: "--create", Description: "Create a new empty host group"},
+			{Text: "--delete", Description: "Delete a host group"},
+			{Text: "--add", Description: "Add hosts matching a selector to a group"},
+			{Text: "--remove", Description: "Remove a host from a group"},
+		}
+	case len(args) == 3 && args[1] != "--create":
+		return groupNameSuggest(session)
+	case len(args) == 4 && args[1] == "--remove":
+		prompts := []prompt.Suggest{}
+		for _, uuid := range session.Hosts.GetGroups()[args[2]] {
+			prompts = append(prompts, prompt.Suggest{Text: uuid})
+		}
+		return prompts
+	case len(args) >= 4 && args[1] == "--add":
+		return hostSelectorSuggest(session, args[len(args)-1])
+	}
+	return []prompt.Suggest{}
+}
+
+func groupNameSuggest(session *session.Session) []prompt.Suggest {
+	prompts := []prompt.Suggest{}
+	groups := session.Hosts.GetGroups()
+	for _, name := range session.Hosts.GroupNames() {
+		prompts = append(prompts, prompt.Suggest{
+			Text:        name,
+			Description: fmt.Sprintf("Group of %d host(s)", len(groups[name])),
+		})
+	}
+	return prompts
+}
+
+// hostSelectorSuggest offers completions for the selector currently being typed. As
+// selectors are comma separated, suggestions are for the last term prefixed with
+// the terms before it.
+func hostSelectorSuggest(session *session.Session, selector string) []prompt.Suggest {
+	prefix := ""
+	if index := strings.LastIndex(selector, ","); index != -1 {
+		prefix = selector[:index+1]
+	}
+
+	terms := groupNameSuggest(session)
+	connected := session.Hosts.GetCurrentHosts()
+	families := map[string]bool{}
+	for _, host := range connected {
+		terms = append(terms, prompt.Suggest{Text: host.UUID, Description: host.ComputerName})
+		families[hosts.PlatformFamily(host.Platform)] = true
+	}
+	familyNames := make([]string, 0)
+	for family := range families {
+		familyNames = append(familyNames, family)
+	}
+	sort.Strings(familyNames)
+	for _, family := range familyNames {
+		terms = append(terms, prompt.Suggest{Text: "platform=" + family, Description: "Connected " + family + " hosts"})
+	}
+	terms = append(terms, prompt.Suggest{Text: "name=", Description: "Hosts whose name matches a glob"})
+
+	prompts := []prompt.Suggest{}
+	for _, term := range terms {
+		prompts = append(prompts, prompt.Suggest{Text: prefix + term.Text, Description: term.Description})
+	}
+	return prompts
+}
@@ -1,16 +1,16 @@
 package commands
 
 import (
+	"context"
 	"sort"
 
-	"github.com/AbGuthrie/goquery/v2/config"
 	"github.com/AbGuthrie/goquery/v2/models"
-	"github.com/AbGuthrie/goquery/v2/utils"
+	"github.com/AbGuthrie/goquery/v2/session"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-func help(api models.GoQueryAPI, config *config.Config, cmdline string) error {
+func help(ctx context.Context, session *session.Session, cmdline string) error {
 	commandNames := make([]string, 0)
 	for k, _ := range CommandMap {
 		commandNames = append(commandNames, k)
@@ -26,7 +26,10 @@ func help(api models.GoQueryAPI, config *config.Config, cmdline string) error {
 		})
 	}
 
-	utils.PrettyPrintQueryResults(helpRows, config.PrintMode)
+	session.PrintResults(models.Results{
+		Columns: []string{"command", "description"},
+		Rows:    helpRows,
+	})
 	return nil
 }
 
@@ -34,6 +37,6 @@ func helpHelp() string {
 	return "Show the help strings for all goquery commands"
 }
 
-func helpSuggest(cmdline string) []prompt.Suggest {
+func helpSuggest(session *session.Session, cmdline string) []prompt.Suggest {
 	return []prompt.Suggest{}
 }
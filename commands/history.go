@@ -1,31 +1,30 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
-	"github.com/AbGuthrie/goquery/v2/config"
-	"github.com/AbGuthrie/goquery/v2/hosts"
-	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-func history(api models.GoQueryAPI, config *config.Config, cmdline string) error {
+func history(ctx context.Context, session *session.Session, cmdline string) error {
 	args := strings.Split(cmdline, " ") // Separate command and arguments
 	if len(args) > 1 {
 		return fmt.Errorf("This command takes no parameters")
 	}
 
-	host, err := hosts.GetCurrentHost()
+	host, err := session.Hosts.GetCurrentHost()
 
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Query Name : Query\n")
+	session.Printf("Query Name : Query\n")
 	for _, query := range host.QueryHistory {
-		fmt.Printf("%s: %s\n", query.Name, query.SQL)
+		session.Printf("%s: %s\n", query.Name, query.SQL)
 	}
 
 	return nil
@@ -35,6 +34,6 @@ func historyHelp() string {
 	return "Print the current host's query history from the current session"
 }
 
-func historySuggest(cmdline string) []prompt.Suggest {
+func historySuggest(session *session.Session, cmdline string) []prompt.Suggest {
 	return []prompt.Suggest{}
 }
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+func listJobs(ctx context.Context, session *session.Session, cmdline string) error {
+	args := strings.Split(cmdline, " ") // Separate command and arguments
+	if len(args) > 1 {
+		return fmt.Errorf("This command takes no parameters")
+	}
+
+	// Prefer host names over UUIDs for hosts we are still connected to
+	hostNames := map[string]string{}
+	for _, host := range session.Hosts.GetCurrentHosts() {
+		hostNames[host.UUID] = host.ComputerName
+	}
+
+	jobRows := make([]map[string]string, 0)
+	for _, job := range session.Jobs.List() {
+		hostName, ok := hostNames[job.HostUUID]
+		if !ok {
+			hostName = job.HostUUID
+		}
+		rowCount := ""
+		if !job.Status.Pending() {
+			rowCount = strconv.Itoa(job.RowCount)
+		}
+		jobRows = append(jobRows, map[string]string{
+			"name":   job.Name,
+			"host":   hostName,
+			"sql":    job.SQL,
+			"status": job.Status.String(),
+			"age":    time.Since(job.Scheduled).Round(time.Second).String(),
+			"rows":   rowCount,
+		})
+	}
+
+	if len(jobRows) == 0 {
+		session.Printf("No scheduled queries are being tracked\n")
+		return nil
+	}
+
+	session.PrintResults(models.Results{
+		Columns: []string{"name", "host", "sql", "status", "age", "rows"},
+		Rows:    jobRows,
+	})
+	return nil
+}
+
+func listJobsHelp() string {
+	return "List queries started with .schedule and whether their results are ready"
+}
+
+func listJobsSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	return []prompt.Suggest{}
+}
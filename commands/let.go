@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+var letAssignment = regexp.MustCompile(`^\.let\s+([^\s=]+)\s*=\s*(.*)$`)
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LetAssignment splits ".let NAME = COMMAND" into the variable name and the command
+// whose results it is set to. Running the command is left to the caller, as it may
+// be an alias or use a pipeline, so ok is false for uses of .let that run as a
+// normal command.
+func LetAssignment(cmdline string) (name, command string, ok bool) {
+	match := letAssignment.FindStringSubmatch(strings.TrimSpace(cmdline))
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], strings.TrimSpace(match[2]), true
+}
+
+// ValidateVariableName returns an error if name can't be referred to as ${name}
+func ValidateVariableName(name string) error {
+	if !variableName.MatchString(name) {
+		return fmt.Errorf("Variable name '%s' must be letters, digits and underscores, not starting with a digit", name)
+	}
+	return nil
+}
+
+func let(ctx context.Context, session *session.Session, cmdline string) error {
+	if _, _, ok := LetAssignment(cmdline); ok {
+		return fmt.Errorf("Variables can only be set by running .let through goquery.Execute")
+	}
+	args := strings.Fields(cmdline)
+
+	// With no arguments, list the variables
+	if len(args) == 1 {
+		printVariables(session)
+		return nil
+	}
+
+	if args[1] != "--remove" {
+		return fmt.Errorf("Usage: .let NAME = COMMAND, or .let --remove NAME")
+	}
+	if len(args) != 3 {
+		return fmt.Errorf("--remove flag requires a variable name argument")
+	}
+	if err := session.RemoveVariable(args[2]); err != nil {
+		return err
+	}
+	session.Printf("Removed variable %s\n", args[2])
+	return nil
+}
+
+func printVariables(session *session.Session) {
+	rows := models.Rows{}
+	for _, name := range session.VariableNames() {
+		results, _ := session.Variable(name)
+		rows = append(rows, map[string]string{
+			"name":    name,
+			"rows":    strconv.Itoa(results.Len()),
+			"columns": strings.Join(results.Columns, ", "),
+		})
+	}
+	session.PrintResults(models.Results{
+		Columns: []string{"name", "rows", "columns"},
+		Rows:    rows,
+	})
+}
+
+func letHelp() string {
+	return "Keep the results of a command in a variable with .let NAME = COMMAND instead of printing them. " +
+		"Later commands can use ${NAME[ROW].COLUMN}, ${NAME.COLUMN} for the first row, or ${NAME[*].COLUMN} for all rows quoted for an IN list, " +
+		"and ${last} is always the most recent results. Run with no arguments to list variables"
+}
+
+func letSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	args := strings.Split(cmdline, " ")
+	if len(args) == 2 {
+		return []prompt.Suggest{
+			prompt.Suggest{Text: "--remove", Description: "Use this flag to remove a variable by name"},
+		}
+	}
+	if len(args) == 3 && args[1] == "--remove" {
+		prompts := []prompt.Suggest{}
+		for _, name := range session.VariableNames() {
+			results, _ := session.Variable(name)
+			prompts = append(prompts, prompt.Suggest{Text: name, Description: fmt.Sprintf("%d row(s)", results.Len())})
+		}
+		return prompts
+	}
+	return []prompt.Suggest{}
+}
@@ -1,58 +1,553 @@
 package commands
 
 import (
+	"context"
 	"fmt"
-	"path/filepath"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/AbGuthrie/goquery/v2/config"
 	"github.com/AbGuthrie/goquery/v2/hosts"
 	"github.com/AbGuthrie/goquery/v2/models"
-	"github.com/AbGuthrie/goquery/v2/utils"
+	"github.com/AbGuthrie/goquery/v2/session"
+	"github.com/AbGuthrie/goquery/v2/sqlquote"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-func listDirectory(api models.GoQueryAPI, config *config.Config, cmdline string) error {
-	host, err := hosts.GetCurrentHost()
+// listTemplate selects what ls shows about files, resolving owners through the
+// users and groups tables, for files matching the condition filled in. Owners are
+// looked up with subqueries as a join would repeat files whose uid or gid is
+// shared by several users or groups, as happens with directory services.
+const listTemplate = "select f.path, f.filename, f.type, f.mode, f.size, f.mtime, f.hard_links, " +
+	"(select username from users where uid = f.uid limit 1) as username, f.uid, " +
+	"(select groupname from groups where gid = f.gid limit 1) as groupname, f.gid from file f where %s"
+
+// Limits on how much of a remote filesystem ls -R walks, as every level is
+// another query
+const (
+	maxListDepth       = 4
+	maxListDirectories = 256
+)
+
+// listFlags are the options ls understands
+var listFlags = []prompt.Suggest{
+	prompt.Suggest{Text: "-l", Description: "Long listing with mode, links, owner, group, size and modification time"},
+	prompt.Suggest{Text: "-a", Description: "Include files whose names start with ."},
+	prompt.Suggest{Text: "-h", Description: "Show sizes in long listings as K, M, G"},
+	prompt.Suggest{Text: "-t", Description: "Sort by modification time, newest first"},
+	prompt.Suggest{Text: "-S", Description: "Sort by size, largest first"},
+	prompt.Suggest{Text: "-r", Description: "Reverse the sort order"},
+	prompt.Suggest{Text: "-R", Description: "List subdirectories recursively, up to 4 levels down"},
+}
+
+type listOptions struct {
+	long, all, human, byTime, bySize, reverse, recursive bool
+}
+
+// listEntry is a file to show, group keeps the files of each listed directory
+// together when sorting
+type listEntry struct {
+	name  string
+	row   map[string]string
+	group int
+}
+
+// listing is a directory to list, with the prefix its files are named with
+type listing struct {
+	path   string
+	prefix string
+	depth  int
+	// target is the argument the directory was given as, when it may turn out to
+	// be a file
+	target string
+}
+
+func listDirectory(ctx context.Context, session *session.Session, cmdline string) error {
+	host, err := session.Hosts.GetCurrentHost()
 	if err != nil {
 		return fmt.Errorf("No host is currently connected: %s", err)
 	}
 
-	args := strings.Split(cmdline, " ") // Separate command and arguments
-	lsDir := "."
-	if len(args) >= 2 {
-		lsDir = cmdline[len(args[0])+1:]
-		if len(lsDir) == 0 {
-			return fmt.Errorf("Invalid Directory")
+	args, err := splitArguments(cmdline)
+	if err != nil {
+		return err
+	}
+	options, targets, err := parseListFlags(args[1:])
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		targets = []string{"."}
+	}
+
+	lister := &directoryLister{
+		session:   session,
+		uuid:      host.UUID,
+		directory: host.CurrentDirectory,
+		paths:     host.Paths(),
+		options:   options,
+	}
+	listings := make([]listing, 0)
+	for _, target := range targets {
+		if !strings.ContainsAny(target, "*?[") {
+			listings = append(listings, listing{path: lister.paths.Resolve(host.CurrentDirectory, target), target: target})
+			continue
+		}
+		matched, err := lister.glob(ctx, target)
+		if err != nil {
+			return err
 		}
+		listings = append(listings, matched...)
+	}
+
+	// Files are named relative to the directory they are in when it is all that's
+	// listed, otherwise relative to the current directory
+	single := len(listings) == 1 && len(lister.entries) == 0 && !options.recursive
+	for i := range listings {
+		if single {
+			listings[i].prefix = ""
+		} else if listings[i].target != "" {
+			listings[i].prefix = lister.prefix(lister.displayName(listings[i].path, listings[i].target))
+		}
+	}
+	if err := lister.list(ctx, listings); err != nil {
+		return err
+	}
+
+	if len(lister.entries) == 0 && len(lister.missing) > 0 {
+		return fmt.Errorf("No such file or directory: %s", strings.Join(lister.missing, ", "))
+	}
+	session.PrintResults(lister.results())
+	if len(lister.missing) > 0 {
+		session.Printf("No such file or directory: %s\n", strings.Join(lister.missing, ", "))
+	}
+	if lister.truncated {
+		fmt.Fprintf(session.Err, "Output truncated, ls -R lists up to %d levels down and %d directories a level\n",
+			maxListDepth, maxListDirectories)
 	}
+	return nil
+}
+
+// directoryLister gathers the files ls shows, querying the host for each level of
+// directories at once
+type directoryLister struct {
+	session   *session.Session
+	uuid      string
+	directory string
+	paths     hosts.Paths
+	options   listOptions
+	entries   []listEntry
+	groups    int
+	missing   []string
+	truncated bool
+}
 
-	if lsDir[0] != '/' {
-		lsDir = host.CurrentDirectory + lsDir
+// glob finds the files matching a pattern, adding them to the listing and
+// returning the directories among them to be listed in turn
+func (lister *directoryLister) glob(ctx context.Context, target string) ([]listing, error) {
+	pattern := lister.paths.Resolve(lister.directory, target)
+	if _, err := lister.paths.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("Invalid pattern: %s", target)
+	}
+	// The file table expands % within a single directory, the pattern itself is
+	// then matched exactly here
+	like := strings.Builder{}
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?':
+			like.WriteString("%")
+		case '[':
+			like.WriteString("%")
+			if end := strings.IndexByte(pattern[i:], ']'); end > 0 {
+				i += end
+			}
+		default:
+			like.WriteByte(pattern[i])
+		}
 	}
-	lsDir = filepath.Clean(lsDir)
 
-	// All directory changes must end with a forward slash
-	if lsDir[len(lsDir)-1] != '/' {
-		lsDir += "/"
+	results, err := lister.session.ScheduleQueryAndWait(ctx, lister.uuid,
+		fmt.Sprintf(listTemplate, "f.path like "+sqlquote.String(like.String())))
+	if err != nil {
+		return nil, err
 	}
+	showHidden := lister.options.all || strings.HasPrefix(pattern[lister.paths.LastSeparator(pattern)+1:], ".")
+	directories := make([]listing, 0)
+	for _, row := range results.Rows {
+		filePath := lister.paths.Clean(row["path"])
+		if matched, _ := lister.paths.Match(pattern, filePath); !matched {
+			continue
+		}
+		if isHidden(row["filename"]) && !showHidden {
+			continue
+		}
+		name := lister.displayName(filePath, target)
+		if row["type"] == "directory" {
+			directories = append(directories, listing{path: filePath, prefix: lister.prefix(name)})
+			continue
+		}
+		lister.entries = append(lister.entries, listEntry{name: name, row: row})
+	}
+	if len(directories) == 0 && len(lister.entries) == 0 {
+		lister.missing = append(lister.missing, target)
+	}
+	return directories, nil
+}
+
+// list lists directories, and with -R their subdirectories, a level at a time
+func (lister *directoryLister) list(ctx context.Context, listings []listing) error {
+	for len(listings) > 0 {
+		directories := make([]string, len(listings))
+		for i, directory := range listings {
+			directories[i] = lister.paths.Directory(directory.path)
+		}
+		results, err := lister.session.ScheduleQueryAndWait(ctx, lister.uuid,
+			fmt.Sprintf(listTemplate, "f.directory in "+sqlquote.List(directories...)))
+		if err != nil {
+			return err
+		}
+		contents := map[string][]map[string]string{}
+		for _, row := range results.Rows {
+			parent := lister.paths.Parent(row["path"])
+			if lister.paths.Equal(parent, row["path"]) {
+				// A root is its own parent but never in its own listing
+				continue
+			}
+			contents[lister.paths.Key(parent)] = append(contents[lister.paths.Key(parent)], row)
+		}
 
-	listQuery := fmt.Sprintf("select * from file where directory = '%s'", lsDir)
-	results, err := utils.ScheduleQueryAndWait(api, host.UUID, listQuery)
+		next := make([]listing, 0)
+		unknown := make([]listing, 0)
+		for _, directory := range listings {
+			rows := contents[lister.paths.Key(directory.path)]
+			if len(rows) == 0 && directory.target != "" {
+				// Either an empty directory or not a directory at all
+				unknown = append(unknown, directory)
+				continue
+			}
+			lister.groups++
+			for _, row := range rows {
+				if isHidden(row["filename"]) && !lister.options.all {
+					continue
+				}
+				lister.entries = append(lister.entries, listEntry{
+					name:  directory.prefix + row["filename"],
+					row:   row,
+					group: lister.groups,
+				})
+				if lister.options.recursive && row["type"] == "directory" {
+					next = append(next, listing{
+						path:   lister.paths.Clean(row["path"]),
+						prefix: directory.prefix + row["filename"] + lister.paths.Separator(),
+						depth:  directory.depth + 1,
+					})
+				}
+			}
+		}
+		if err := lister.stat(ctx, unknown); err != nil {
+			return err
+		}
 
+		if len(next) > 0 && next[0].depth > maxListDepth {
+			lister.truncated = true
+			break
+		}
+		if len(next) > maxListDirectories {
+			lister.truncated = true
+			next = next[:maxListDirectories]
+		}
+		listings = next
+	}
+	return nil
+}
+
+// stat looks up targets that listed nothing, adding those that are files and
+// noting those that don't exist
+func (lister *directoryLister) stat(ctx context.Context, targets []listing) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(targets)*2)
+	for _, target := range targets {
+		// Directories are matched with a trailing slash, like cd checks them
+		filePath := lister.paths.Clean(target.path)
+		paths = append(paths, filePath, lister.paths.Directory(filePath))
+	}
+	results, err := lister.session.ScheduleQueryAndWait(ctx, lister.uuid,
+		fmt.Sprintf(listTemplate, "f.path in "+sqlquote.List(paths...)))
 	if err != nil {
 		return err
 	}
-
-	utils.PrettyPrintQueryResults(results, config.PrintMode)
+	found := map[string]map[string]string{}
+	for _, row := range results.Rows {
+		found[lister.paths.Key(row["path"])] = row
+	}
+	for _, target := range targets {
+		row, ok := found[lister.paths.Key(target.path)]
+		switch {
+		case !ok:
+			lister.missing = append(lister.missing, target.target)
+		case row["type"] != "directory":
+			lister.entries = append(lister.entries, listEntry{name: target.target, row: row})
+		}
+	}
 	return nil
 }
 
+// displayName names a file the way it was asked for, relative to the current
+// directory unless given as an absolute path
+func (lister *directoryLister) displayName(filePath, target string) string {
+	if lister.paths.IsAbs(target) {
+		return filePath
+	}
+	if relative, ok := lister.paths.TrimDirectory(filePath, lister.directory); ok {
+		return relative
+	}
+	return filePath
+}
+
+// prefix is what the files in a directory displayed as name are prefixed with
+func (lister *directoryLister) prefix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.TrimSuffix(name, lister.paths.Separator()) + lister.paths.Separator()
+}
+
+// results sorts the entries and converts them to the columns of the listing
+func (lister *directoryLister) results() models.Results {
+	options := lister.options
+	entries := lister.entries
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		less := a.name < b.name
+		switch {
+		case options.byTime && a.row["mtime"] != b.row["mtime"]:
+			less = numberValue(a.row["mtime"]) > numberValue(b.row["mtime"])
+		case options.bySize && a.row["size"] != b.row["size"]:
+			less = numberValue(a.row["size"]) > numberValue(b.row["size"])
+		}
+		return less != options.reverse
+	})
+
+	if !options.long {
+		results := models.Results{Columns: []string{"name"}, Rows: models.Rows{}}
+		for _, entry := range entries {
+			results.Rows = append(results.Rows, map[string]string{"name": entry.name})
+		}
+		return results
+	}
+
+	results := models.Results{
+		Columns: []string{"mode", "links", "owner", "group", "size", "modified", "name"},
+		Rows:    models.Rows{},
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		row := entry.row
+		size := row["size"]
+		if options.human {
+			size = humanSize(numberValue(size))
+		}
+		results.Rows = append(results.Rows, map[string]string{
+			"mode":     modeString(row["type"], row["mode"]),
+			"links":    row["hard_links"],
+			"owner":    firstNonEmpty(row["username"], row["uid"]),
+			"group":    firstNonEmpty(row["groupname"], row["gid"]),
+			"size":     size,
+			"modified": modifiedTime(numberValue(row["mtime"]), now),
+			"name":     entry.name,
+		})
+	}
+	return results
+}
+
+// parseListFlags separates flags like -la from the files to list
+func parseListFlags(args []string) (listOptions, []string, error) {
+	options := listOptions{}
+	targets := make([]string, 0)
+	flagsDone := false
+	for _, arg := range args {
+		if flagsDone || !strings.HasPrefix(arg, "-") || arg == "-" {
+			targets = append(targets, arg)
+			continue
+		}
+		if arg == "--" {
+			flagsDone = true
+			continue
+		}
+		for _, flag := range arg[1:] {
+			switch flag {
+			case 'l':
+				options.long = true
+			case 'a':
+				options.all = true
+			case 'h':
+				options.human = true
+			case 't':
+				options.byTime = true
+			case 'S':
+				options.bySize = true
+			case 'r':
+				options.reverse = true
+			case 'R':
+				options.recursive = true
+			default:
+				return options, nil, fmt.Errorf("Unknown flag -%c, ls supports -l -a -h -t -S -r -R", flag)
+			}
+		}
+	}
+	return options, targets, nil
+}
+
+// splitArguments splits a command line on spaces, keeping quoted arguments whole
+// so paths with spaces can be given. A doubled quote inside quotes is a literal
+// one, so values quoted for SQL by variables come out as they were.
+func splitArguments(cmdline string) ([]string, error) {
+	args := make([]string, 0)
+	current := strings.Builder{}
+	inArgument := false
+	var quote rune
+	chars := []rune(cmdline)
+	for i := 0; i < len(chars); i++ {
+		char := chars[i]
+		switch {
+		case quote != 0 && char == quote && i+1 < len(chars) && chars[i+1] == quote:
+			// A doubled quote is a literal one, as in SQL
+			current.WriteRune(char)
+			i++
+		case quote != 0 && char == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(char)
+		case char == '\'' || char == '"':
+			quote = char
+			inArgument = true
+		case char == ' ' || char == '\t':
+			if inArgument {
+				args = append(args, current.String())
+				current.Reset()
+				inArgument = false
+			}
+		default:
+			current.WriteRune(char)
+			inArgument = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("Unterminated quote")
+	}
+	if inArgument {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func numberValue(value string) int64 {
+	number, _ := strconv.ParseInt(value, 10, 64)
+	return number
+}
+
+// modeString formats a file type and octal mode like ls, as in drwxr-xr-x
+func modeString(fileType, mode string) string {
+	bits, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return mode
+	}
+	typeChars := map[string]byte{
+		"directory": 'd', "symlink": 'l', "symbolic": 'l', "character": 'c',
+		"block": 'b', "fifo": 'p', "socket": 's',
+	}
+	formatted := []byte("-rwxrwxrwx")
+	if char, ok := typeChars[fileType]; ok {
+		formatted[0] = char
+	}
+	for i := 0; i < 9; i++ {
+		if bits&(1<<uint(8-i)) == 0 {
+			formatted[i+1] = '-'
+		}
+	}
+	// Set user ID, set group ID and sticky bits replace the execute permissions
+	special := []struct {
+		bit       uint64
+		position  int
+		set, only byte
+	}{
+		{0o4000, 3, 's', 'S'},
+		{0o2000, 6, 's', 'S'},
+		{0o1000, 9, 't', 'T'},
+	}
+	for _, s := range special {
+		if bits&s.bit == 0 {
+			continue
+		}
+		if formatted[s.position] == '-' {
+			formatted[s.position] = s.only
+		} else {
+			formatted[s.position] = s.set
+		}
+	}
+	return string(formatted)
+}
+
+// humanSize formats a size in bytes like ls -h, as in 4.0K or 12M
+func humanSize(size int64) string {
+	if size < 1024 {
+		return strconv.FormatInt(size, 10)
+	}
+	// Sizes are rounded up, so the unit is chosen after rounding to show 1.0M
+	// rather than 1024K
+	value := float64(size)
+	unit := 0
+	for math.Ceil(value) >= 1024 && unit < 5 {
+		value /= 1024
+		unit++
+	}
+	suffix := "KMGTP"[unit-1]
+	if tenths := math.Ceil(value*10) / 10; tenths < 10 {
+		return fmt.Sprintf("%.1f%c", tenths, suffix)
+	}
+	return fmt.Sprintf("%.0f%c", math.Ceil(value), suffix)
+}
+
+// modifiedTime formats a modification time like ls, with the time of day for
+// files changed in the last six months and the year for older ones
+func modifiedTime(unix int64, now time.Time) string {
+	modified := time.Unix(unix, 0)
+	if now.Sub(modified) > 182*24*time.Hour || modified.After(now.Add(time.Hour)) {
+		return modified.Format("Jan _2  2006")
+	}
+	return modified.Format("Jan _2 15:04")
+}
+
 func listDirectoryHelp() string {
-	return "List the files in the current directory on the remote host"
+	return "List files on the remote host like ls, -l for details, -a for hidden files, -h for readable sizes, " +
+		"-t or -S to sort by time or size, -r to reverse and -R to recurse, with patterns like *.plist"
 }
 
-func listDirectorySuggest(cmdline string) []prompt.Suggest {
-	return []prompt.Suggest{}
+func listDirectorySuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	args := strings.Split(cmdline, " ")
+	if strings.HasPrefix(args[len(args)-1], "-") {
+		return listFlags
+	}
+	return remotePathSuggest(session, cmdline, false)
 }
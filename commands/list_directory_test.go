@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArguments(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		want    []string
+	}{
+		{".ls", []string{".ls"}},
+		{".ls  -la   /etc", []string{".ls", "-la", "/etc"}},
+		{`.cat "/tmp/a file"`, []string{".cat", "/tmp/a file"}},
+		{".cat '/tmp/it''s'", []string{".cat", "/tmp/it's"}},
+		{`.cat "say ""hi"""`, []string{".cat", `say "hi"`}},
+		{".cat ''", []string{".cat", ""}},
+		{`.cat "it's"`, []string{".cat", "it's"}},
+		{".get a'b c'd", []string{".get", "ab cd"}},
+	}
+	for _, test := range tests {
+		got, err := splitArguments(test.cmdline)
+		if err != nil {
+			t.Errorf("splitArguments(%q) failed: %s", test.cmdline, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitArguments(%q) = %q, want %q", test.cmdline, got, test.want)
+		}
+	}
+	if got, err := splitArguments(".cat 'unterminated"); err == nil {
+		t.Errorf("splitArguments with an unterminated quote = %q, want an error", got)
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0"},
+		{1023, "1023"},
+		{1024, "1.0K"},
+		{1025, "1.1K"},
+		{10239, "10K"},
+		{10240, "10K"},
+		{1048575, "1.0M"},
+		{1048576, "1.0M"},
+		{5 << 30, "5.0G"},
+		{1<<40 - 1, "1.0T"},
+		{2048 << 50, "2048P"},
+	}
+	for _, test := range tests {
+		if got := humanSize(test.size); got != test.want {
+			t.Errorf("humanSize(%d) = %q, want %q", test.size, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+func local(ctx context.Context, session *session.Session, cmdline string) error {
+	args := strings.Fields(cmdline)
+
+	// With no SQL, list the results that can be queried
+	if len(args) == 1 {
+		printLocalTables(session)
+		return nil
+	}
+
+	if args[1] == "--name" {
+		return nameLocalTable(session, args[2:])
+	}
+
+	query := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cmdline), args[0]))
+	results, err := session.Local.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	session.PrintQueryResults(results)
+	return nil
+}
+
+func nameLocalTable(session *session.Session, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("Usage: .local --name NAME [QUERY_NAME]")
+	}
+	tables := session.Local.Tables()
+	queryName := ""
+	if len(args) == 2 {
+		queryName = args[1]
+	} else if len(tables) > 0 {
+		queryName = tables[len(tables)-1].Name
+	} else {
+		return fmt.Errorf("No results have been fetched yet")
+	}
+
+	if err := session.Local.Alias(args[0], queryName); err != nil {
+		return err
+	}
+	session.Printf("Results of %s can be queried as %s\n", queryName, args[0])
+	return nil
+}
+
+func printLocalTables(session *session.Session) {
+	rows := models.Rows{}
+	for _, table := range session.Local.Tables() {
+		rows = append(rows, map[string]string{
+			"name":    table.Name,
+			"aliases": strings.Join(table.Aliases, ", "),
+			"host":    table.Host,
+			"sql":     table.SQL,
+			"rows":    strconv.Itoa(table.Rows),
+		})
+	}
+	session.PrintResults(models.Results{
+		Columns: []string{"name", "aliases", "host", "sql", "rows"},
+		Rows:    rows,
+	})
+}
+
+func localHelp() string {
+	return "Run SQL over the results fetched so far in this session, each stored as a table named after its query. " +
+		"Run with no SQL to list them, or use .local --name NAME [QUERY_NAME] to give the latest or named results a shorter name"
+}
+
+func localSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	args := strings.Split(cmdline, " ")
+	if len(args) == 2 {
+		return []prompt.Suggest{
+			prompt.Suggest{Text: "--name", Description: "Give fetched results a name to query them by"},
+			prompt.Suggest{Text: "select", Description: "Query the fetched results"},
+		}
+	}
+
+	if len(args) < 2 {
+		return []prompt.Suggest{}
+	}
+
+	// Suggest tables where SQL expects one, and the results to name after --name NAME
+	previous := strings.ToLower(args[len(args)-2])
+	if previous != "from" && previous != "join" && !(len(args) == 4 && args[1] == "--name") {
+		return []prompt.Suggest{}
+	}
+	prompts := []prompt.Suggest{}
+	for _, table := range session.Local.Tables() {
+		description := fmt.Sprintf("%s on %s", table.SQL, table.Host)
+		if len(args) != 4 || args[1] != "--name" {
+			for _, alias := range table.Aliases {
+				prompts = append(prompts, prompt.Suggest{Text: alias, Description: description})
+			}
+		}
+		prompts = append(prompts, prompt.Suggest{Text: table.Name, Description: description})
+	}
+	return prompts
+}
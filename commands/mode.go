@@ -1,63 +1,49 @@
 package commands
 
 import (
+	"context"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/AbGuthrie/goquery/v2/config"
-	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/printers"
+	"github.com/AbGuthrie/goquery/v2/session"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-var validModes = map[string]config.PrintModeEnum{
-	"json":   config.PrintJSON,
-	"line":   config.PrintLine,
-	"pretty": config.PrintPretty,
-}
-
-func changeMode(api models.GoQueryAPI, config *config.Config, cmdline string) error {
+func changeMode(ctx context.Context, session *session.Session, cmdline string) error {
 	args := strings.Split(cmdline, " ") // Separate command and arguments
 	if len(args) == 1 {
 		return fmt.Errorf("Mode parameter required")
 	}
 	modeArg := args[1]
 
-	// Assert valid mode
-	mode, ok := validModes[modeArg]
-	if !ok {
-		return fmt.Errorf("%s is not a valid print mode", modeArg)
+	// Assert a printer is registered for the mode
+	if _, err := printers.Get(modeArg); err != nil {
+		return err
 	}
 
-	config.SetPrintMode(mode)
-	fmt.Printf("Print mode set to '%s'.\n", modeArg)
+	session.Config.SetPrintMode(config.PrintModeEnum(modeArg))
+	session.Printf("Print mode set to '%s'.\n", modeArg)
 
 	return nil
 }
 
 func changeModeHelp() string {
-	modeNames := make([]string, 0)
-	for mode := range validModes {
-		modeNames = append(modeNames, mode)
-	}
-	sort.Strings(modeNames)
-	return fmt.Sprintf("Change print mode (%s)", strings.Join(modeNames, ", "))
+	return fmt.Sprintf("Change print mode (%s)", strings.Join(printers.Names(), ", "))
 }
 
-func changeModeSuggest(cmdline string) []prompt.Suggest {
+func changeModeSuggest(session *session.Session, cmdline string) []prompt.Suggest {
 	prompts := []prompt.Suggest{}
-
-	modeNames := make([]string, 0)
-	for mode := range validModes {
-		modeNames = append(modeNames, mode)
-	}
-	sort.Strings(modeNames)
-
-	for _, mode := range modeNames {
+	for _, mode := range printers.Names() {
+		description := ""
+		if config.PrintModeEnum(mode) == session.Config.PrintMode {
+			description = "Current print mode"
+		}
 		prompts = append(prompts, prompt.Suggest{
 			Text:        mode,
-			Description: "",
+			Description: description,
 		})
 	}
 
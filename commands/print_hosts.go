@@ -1,18 +1,17 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
-	"github.com/AbGuthrie/goquery/v2/config"
-	"github.com/AbGuthrie/goquery/v2/hosts"
 	"github.com/AbGuthrie/goquery/v2/models"
-	"github.com/AbGuthrie/goquery/v2/utils"
+	"github.com/AbGuthrie/goquery/v2/session"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-func printHosts(api models.GoQueryAPI, config *config.Config, cmdline string) error {
+func printHosts(ctx context.Context, session *session.Session, cmdline string) error {
 	args := strings.Split(cmdline, " ") // Separate command and arguments
 	if len(args) > 1 {
 		return fmt.Errorf("This command takes no parameters")
@@ -20,7 +19,7 @@ func printHosts(api models.GoQueryAPI, config *config.Config, cmdline string) er
 
 	hostRows := make([]map[string]string, 0)
 
-	for _, host := range hosts.GetCurrentHosts() {
+	for _, host := range session.Hosts.GetCurrentHosts() {
 		hostRows = append(hostRows, map[string]string{
 			"UUID":              host.UUID,
 			"Name":              host.ComputerName,
@@ -31,7 +30,10 @@ func printHosts(api models.GoQueryAPI, config *config.Config, cmdline string) er
 		})
 	}
 
-	utils.PrettyPrintQueryResults(hostRows, config.PrintMode)
+	session.PrintResults(models.Results{
+		Columns: []string{"UUID", "Name", "Platform", "Version", "Username", "Current Directory"},
+		Rows:    hostRows,
+	})
 
 	return nil
 }
@@ -40,6 +42,6 @@ func printHostsHelp() string {
 	return "Prints out all connected hosts"
 }
 
-func printHostsSuggest(cmdline string) []prompt.Suggest {
+func printHostsSuggest(session *session.Session, cmdline string) []prompt.Suggest {
 	return []prompt.Suggest{}
 }
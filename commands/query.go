@@ -1,19 +1,17 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
-	"github.com/AbGuthrie/goquery/v2/config"
-	"github.com/AbGuthrie/goquery/v2/hosts"
-	"github.com/AbGuthrie/goquery/v2/models"
-	"github.com/AbGuthrie/goquery/v2/utils"
+	"github.com/AbGuthrie/goquery/v2/session"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-func query(api models.GoQueryAPI, config *config.Config, cmdline string) error {
-	host, err := hosts.GetCurrentHost()
+func query(ctx context.Context, session *session.Session, cmdline string) error {
+	host, err := session.Hosts.GetCurrentHost()
 	if err != nil {
 		return fmt.Errorf("No host is currently connected: %s", err)
 	}
@@ -24,13 +22,13 @@ func query(api models.GoQueryAPI, config *config.Config, cmdline string) error {
 	}
 	// TODO This needs to support Unicode/Runes
 	commandStripped := cmdline[strings.Index(cmdline, " ")+1:]
-	results, err := utils.ScheduleQueryAndWait(api, host.UUID, commandStripped)
+	results, err := session.ScheduleQueryAndWait(ctx, host.UUID, commandStripped)
 
 	if err != nil {
 		return err
 	}
 
-	utils.PrettyPrintQueryResults(results, config.PrintMode)
+	session.PrintQueryResults(results)
 
 	return nil
 }
@@ -39,7 +37,7 @@ func queryHelp() string {
 	return "Schedule a query on a host and wait for results"
 }
 
-func querySuggest(cmdline string) []prompt.Suggest {
+func querySuggest(session *session.Session, cmdline string) []prompt.Suggest {
 	parts := strings.Split(cmdline, " ")
 	// The cmdline doesn't have enough components
 	if len(parts) < 2 {
@@ -54,12 +52,12 @@ func querySuggest(cmdline string) []prompt.Suggest {
 	prompts := []prompt.Suggest{}
 
 	// There is no connected host
-	host, err := hosts.GetCurrentHost()
+	host, err := session.Hosts.GetCurrentHost()
 	if err != nil {
 		return prompts
 	}
 	for _, table := range host.Tables {
-		prompts = append(prompts, prompt.Suggest{table, ""})
+		prompts = append(prompts, prompt.Suggest{Text: table, Description: ""})
 	}
 
 	return prompts
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbGuthrie/goquery/v2/hosts"
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+// hostQueryResult is the outcome of running a fanned out query on a single host
+type hostQueryResult struct {
+	host    hosts.Host
+	results models.Results
+	err     error
+}
+
+// defaultHostTimeout bounds how long a fanned out query waits for each host, so
+// one host that never checks in doesn't hold up the results of the others
+const defaultHostTimeout = 5 * time.Minute
+
+func queryAll(ctx context.Context, session *session.Session, cmdline string) error {
+	targets := session.Hosts.GetCurrentHosts()
+	hostTimeout := defaultHostTimeout
+	query := trimFields(cmdline, 1)
+	for {
+		args := strings.Fields(query)
+		if len(args) == 0 || (args[0] != "--hosts" && args[0] != "--timeout") {
+			break
+		}
+		if len(args) < 2 {
+			return fmt.Errorf("%s requires a value followed by a query", args[0])
+		}
+		if args[0] == "--hosts" {
+			selected, err := session.Hosts.Select(args[1])
+			if err != nil {
+				return err
+			}
+			targets = selected
+		} else {
+			timeout, ok := parseInterval(args[1])
+			if !ok {
+				return fmt.Errorf("Invalid timeout %s, give seconds or a duration like 30s or 2m", args[1])
+			}
+			hostTimeout = timeout
+		}
+		query = trimFields(query, 2)
+	}
+	if query == "" {
+		return fmt.Errorf("A query to run must be provided")
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("No hosts are currently connected")
+	}
+
+	hostResults := fanOutQuery(ctx, session, targets, query, hostTimeout)
+
+	// Merge every host's rows into one table, tagging each with its origin. Hosts may
+	// return different columns (select * across osquery versions) so keep them all
+	// in the order they were first seen.
+	merged := models.Results{Columns: []string{"host", "host_uuid"}, Rows: models.Rows{}}
+	seenColumns := map[string]bool{"host": true, "host_uuid": true}
+	failures := make([]hostQueryResult, 0)
+	for _, hostResult := range hostResults {
+		if hostResult.err != nil {
+			failures = append(failures, hostResult)
+			continue
+		}
+		for _, column := range hostResult.results.Columns {
+			if !seenColumns[column] {
+				seenColumns[column] = true
+				merged.Columns = append(merged.Columns, column)
+			}
+		}
+		for _, row := range hostResult.results.Rows {
+			mergedRow := map[string]string{
+				"host":      hostResult.host.ComputerName,
+				"host_uuid": hostResult.host.UUID,
+			}
+			for column, value := range row {
+				mergedRow[column] = value
+			}
+			merged.Rows = append(merged.Rows, mergedRow)
+		}
+	}
+
+	session.PrintQueryResults(merged)
+
+	if len(failures) == 0 {
+		return nil
+	}
+	fmt.Fprintf(session.Err, "No results from %d host(s):\n", len(failures))
+	for _, failure := range failures {
+		fmt.Fprintf(session.Err, "  %s (%s): %s\n", failure.host.ComputerName, failure.host.UUID, failure.err)
+	}
+	return fmt.Errorf("Query failed on %d of %d host(s)", len(failures), len(hostResults))
+}
+
+// fanOutQuery schedules query on every target concurrently and waits up to hostTimeout
+// for each of them, printing a line of progress as each host finishes. Results are
+// returned in target order.
+func fanOutQuery(ctx context.Context, session *session.Session, targets []hosts.Host, query string,
+	hostTimeout time.Duration) []hostQueryResult {
+	hostResults := make([]hostQueryResult, len(targets))
+	var waitGroup sync.WaitGroup
+	var printMutex sync.Mutex
+	completed := 0
+
+	fmt.Fprintf(session.Err, "Scheduling query on %d host(s)\n", len(targets))
+	for i, host := range targets {
+		waitGroup.Add(1)
+		go func(i int, host hosts.Host) {
+			defer waitGroup.Done()
+			started := time.Now()
+			hostCtx, cancel := context.WithTimeout(ctx, hostTimeout)
+			defer cancel()
+			results, err := scheduleAndWaitQuietly(hostCtx, session, host.UUID, query)
+			if err != nil && hostCtx.Err() == context.DeadlineExceeded {
+				err = fmt.Errorf("Timed out after %s waiting for results", time.Since(started).Round(time.Second))
+			}
+			hostResults[i] = hostQueryResult{host: host, results: results, err: err}
+
+			printMutex.Lock()
+			defer printMutex.Unlock()
+			completed++
+			if err != nil {
+				fmt.Fprintf(session.Err, "[%d/%d] %s: %s\n", completed, len(targets), host.ComputerName, err)
+				return
+			}
+			fmt.Fprintf(session.Err, "[%d/%d] %s: %d row(s) in %s\n", completed, len(targets), host.ComputerName,
+				results.Len(), time.Since(started).Round(time.Second))
+		}(i, host)
+	}
+	waitGroup.Wait()
+
+	return hostResults
+}
+
+func scheduleAndWaitQuietly(ctx context.Context, session *session.Session, uuid, query string) (models.Results, error) {
+	queryName, err := session.ScheduleQuery(ctx, uuid, query)
+	if err != nil {
+		return models.Results{}, fmt.Errorf("Could not schedule query: %s", err)
+	}
+	return session.WaitForResults(ctx, queryName, func() {})
+}
+
+func queryAllHelp() string {
+	return "Run a query on all connected hosts (or --hosts SELECTOR) and merge the results, " +
+		"waiting up to 5 minutes (or --timeout DURATION) for each host"
+}
+
+func queryAllSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	args := strings.Split(cmdline, " ")
+	previous := ""
+	if len(args) >= 2 {
+		previous = args[len(args)-2]
+	}
+	switch {
+	case previous == "--hosts":
+		return hostSelectorSuggest(session, args[len(args)-1])
+	case previous == "--timeout":
+		return []prompt.Suggest{}
+	case len(args) == 2 || len(args) == 4 && strings.HasPrefix(args[1], "--"):
+		prompts := []prompt.Suggest{}
+		if !containsArg(args, "--hosts") {
+			prompts = append(prompts, prompt.Suggest{Text: "--hosts", Description: "Select hosts by group, platform=, name glob or UUID"})
+		}
+		if !containsArg(args, "--timeout") {
+			prompts = append(prompts, prompt.Suggest{Text: "--timeout", Description: "How long to wait for each host, like 30s or 2m"})
+		}
+		return prompts
+	}
+	return querySuggest(session, cmdline)
+}
+
+func containsArg(args []string, target string) bool {
+	for _, arg := range args {
+		if arg == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+// remotePathSuggest suggests the files in the directory of the path being typed
+// as the last argument, or only the directories when directoriesOnly. Listings
+// come from the session's cache, so the first suggestions for a directory appear
+// once its listing has been fetched in the background.
+func remotePathSuggest(session *session.Session, cmdline string, directoriesOnly bool) []prompt.Suggest {
+	host, err := session.Hosts.GetCurrentHost()
+	if err != nil {
+		return []prompt.Suggest{}
+	}
+	args := strings.Split(cmdline, " ")
+	typed := args[len(args)-1]
+
+	// Suggestions replace the whole argument, so keep the directory part as typed
+	paths := host.Paths()
+	typedDirectory := typed[:paths.LastSeparator(typed)+1]
+	directory := host.CurrentDirectory
+	if typedDirectory != "" {
+		directory = paths.Directory(paths.Resolve(host.CurrentDirectory, typedDirectory))
+	}
+	showHidden := strings.HasPrefix(typed[len(typedDirectory):], ".")
+
+	suggestions := []prompt.Suggest{}
+	for _, entry := range session.DirectoryEntries(host.UUID, directory) {
+		if directoriesOnly && !entry.Directory || isHidden(entry.Name) && !showHidden {
+			continue
+		}
+		suggestion := prompt.Suggest{Text: typedDirectory + entry.Name, Description: "file"}
+		if entry.Directory {
+			suggestion = prompt.Suggest{Text: typedDirectory + entry.Name + paths.Separator(), Description: "directory"}
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].Text < suggestions[j].Text
+	})
+	return prompt.FilterHasPrefix(suggestions, typed, paths.Windows)
+}
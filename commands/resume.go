@@ -1,52 +1,61 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
-	"github.com/AbGuthrie/goquery/v2/config"
-	"github.com/AbGuthrie/goquery/v2/hosts"
-	"github.com/AbGuthrie/goquery/v2/models"
-	"github.com/AbGuthrie/goquery/v2/utils"
+	"github.com/AbGuthrie/goquery/v2/session"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-func resume(api models.GoQueryAPI, config *config.Config, cmdline string) error {
+func resume(ctx context.Context, session *session.Session, cmdline string) error {
 	args := strings.Split(cmdline, " ") // Separate command and arguments
+	var queryName string
 	if len(args) == 1 {
-		return fmt.Errorf("A query name to resume must be provided")
+		// Default to the most recently finished background job
+		job, err := session.Jobs.LatestFinished()
+		if err != nil {
+			return fmt.Errorf("A query name to resume must be provided: %s", err)
+		}
+		queryName = job.Name
+	} else {
+		// TODO This needs to support Unicode/Runes
+		queryName = cmdline[strings.Index(cmdline, " ")+1:]
 	}
-	// TODO This needs to support Unicode/Runes
-	commandStripped := cmdline[strings.Index(cmdline, " ")+1:]
-	results, status, err := api.FetchResults(commandStripped)
+	results, status, err := session.FetchResults(ctx, queryName)
 
 	if err != nil {
 		return err
 	}
 
-	if status == "Pending" {
-		return fmt.Errorf("Query does not have results available yet")
+	// Failed and expired jobs are finished too, so they stop counting as ready
+	if !status.Pending() {
+		session.Jobs.MarkRead(queryName)
+	}
+	if err := status.Err(); err != nil {
+		return err
 	}
 
-	utils.PrettyPrintQueryResults(results, config.PrintMode)
+	session.PrintQueryResults(results)
 
 	return nil
 }
 
 func resumeHelp() string {
-	return "Try to fetch results for a query but don't block if unavailable"
+	return "Try to fetch results for a query but don't block if unavailable, defaults to the latest finished job"
 }
 
-func resumeSuggest(cmdline string) []prompt.Suggest {
-	host, err := hosts.GetCurrentHost()
+func resumeSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	host, err := session.Hosts.GetCurrentHost()
 	prompts := []prompt.Suggest{}
 	if err != nil {
 		return prompts
 	}
 
 	for _, query := range host.QueryHistory {
-		prompts = append(prompts, prompt.Suggest{query.Name, query.SQL})
+		prompts = append(prompts, prompt.Suggest{Text: query.Name, Description: query.SQL})
 	}
 	return prompts
 }
@@ -1,18 +1,17 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
-	"github.com/AbGuthrie/goquery/v2/config"
-	"github.com/AbGuthrie/goquery/v2/hosts"
-	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/session"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-func schedule(api models.GoQueryAPI, config *config.Config, cmdline string) error {
-	host, err := hosts.GetCurrentHost()
+func schedule(ctx context.Context, session *session.Session, cmdline string) error {
+	host, err := session.Hosts.GetCurrentHost()
 	if err != nil {
 		return fmt.Errorf("No host is currently connected: %s", err)
 	}
@@ -23,21 +22,21 @@ func schedule(api models.GoQueryAPI, config *config.Config, cmdline string) erro
 	}
 	// TODO This needs to support Unicode/Runes
 	commandStripped := cmdline[strings.Index(cmdline, " ")+1:]
-	queryName, err := api.ScheduleQuery(host.UUID, commandStripped)
-
+	queryName, err := session.ScheduleQuery(ctx, host.UUID, commandStripped)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Scheduled query for host. Resume with name: %s\n", queryName)
+	session.Jobs.Track(host.UUID, queryName, commandStripped)
+	session.Printf("Scheduled query for host. Resume with name: %s\n", queryName)
 
 	return nil
 }
 
 func scheduleHelp() string {
-	return "Schedule a query on a host but don't wait for results"
+	return "Schedule a query on a host but don't wait for results, track it with .jobs"
 }
 
-func scheduleSuggest(cmdline string) []prompt.Suggest {
-	return querySuggest(cmdline)
+func scheduleSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	return querySuggest(session, cmdline)
 }
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+func tee(ctx context.Context, session *session.Session, cmdline string) error {
+	args := strings.Fields(cmdline)
+
+	// With no file, stop mirroring output
+	if len(args) == 1 {
+		filePath, err := session.StopTee()
+		if err != nil {
+			return err
+		}
+		if filePath == "" {
+			return fmt.Errorf("Output is not being written to a file, provide one to start")
+		}
+		session.Printf("Stopped writing output to %s\n", filePath)
+		return nil
+	}
+
+	appendOutput := false
+	if args[1] == "-a" || args[1] == "--append" {
+		appendOutput = true
+		args = args[1:]
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("Usage: .tee [--append] FILE")
+	}
+
+	if err := session.StartTee(args[1], appendOutput); err != nil {
+		return err
+	}
+	// Written to Out alone so the file only holds command output
+	fmt.Fprintf(session.Out, "Writing output to %s, run .tee again to stop\n", args[1])
+	return nil
+}
+
+func teeHelp() string {
+	return "Also write all following command output to a file, in the current print mode. " +
+		"Use .tee --append FILE to add to an existing file and .tee on its own to stop"
+}
+
+func teeSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	args := strings.Split(cmdline, " ")
+	if len(args) != 2 || args[1] != "" {
+		return []prompt.Suggest{}
+	}
+	// Running .tee on its own is how output already being written is stopped
+	if session.TeePath() != "" {
+		return []prompt.Suggest{}
+	}
+	return []prompt.Suggest{
+		prompt.Suggest{Text: "--append", Description: "Add to the end of the file instead of replacing it"},
+	}
+}
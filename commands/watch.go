@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbGuthrie/goquery/v2/diff"
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/printers"
+	"github.com/AbGuthrie/goquery/v2/session"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+const defaultWatchInterval = 10 * time.Second
+
+// Terminal escape sequences used to redraw watched results
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	green       = "\x1b[32m"
+	red         = "\x1b[31m"
+	resetColor  = "\x1b[0m"
+)
+
+// watchedRow matches the first line of an added or removed row as the pretty and
+// line print modes draw them
+var watchedRow = regexp.MustCompile(`^(\| (added|removed) |\s*change = (added|removed)$)`)
+
+func watch(ctx context.Context, session *session.Session, cmdline string) error {
+	args := strings.Fields(cmdline)
+	if len(args) == 1 {
+		return fmt.Errorf("A query to watch must be provided")
+	}
+	host, err := session.Hosts.GetCurrentHost()
+	if err != nil {
+		return err
+	}
+
+	interval, hasInterval := parseInterval(args[1])
+	query := trimFields(cmdline, 1)
+	if hasInterval {
+		query = trimFields(cmdline, 2)
+	}
+	if query == "" {
+		return fmt.Errorf("A query to watch must be provided")
+	}
+
+	// Watching runs until interrupted, by Ctrl-C or ctx being cancelled. The command
+	// timeout applies to each run of the query rather than the whole watch, so ctx's
+	// deadline is left out.
+	watchCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+	defer stop()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		parentDone := ctx.Done()
+		for {
+			select {
+			case <-interrupt:
+				stop()
+				return
+			case <-parentDone:
+				if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					stop()
+					return
+				}
+				parentDone = nil
+			case <-watchCtx.Done():
+				return
+			}
+		}
+	}()
+
+	redraw := printers.TerminalWidth(session.Out) > 0
+	var previous *models.Results
+	for run := 1; ; run++ {
+		runCtx, cancel := session.Config.CommandContext(watchCtx)
+		// Runs aren't recorded, so a long watch doesn't fill the host's history and
+		// the local results with copies of the same query
+		results, err := session.QueryUnrecorded(runCtx, host.UUID, query)
+		cancel()
+		if watchCtx.Err() != nil {
+			session.Printf("\nStopped watching\n")
+			return nil
+		}
+
+		heading := fmt.Sprintf("Every %s on %s: %s | run %d at %s", interval, host.ComputerName, query, run,
+			time.Now().Format("15:04:05"))
+		output := ""
+		if err != nil {
+			heading += fmt.Sprintf(" | failed: %s", err)
+		} else {
+			if previous == nil {
+				previous = &results
+			}
+			annotated, summary := diff.Annotate(*previous, results)
+			previous = &results
+			heading += fmt.Sprintf(" | %d row(s), %d added, %d removed", results.Len(), summary.Added, summary.Removed)
+			rendered, ok := session.RenderResults(annotated)
+			if !ok {
+				return fmt.Errorf("Could not filter results")
+			}
+			output = rendered
+		}
+
+		// RenderResults has already written the results to any tee file, so the
+		// redraw only goes to Out
+		if redraw {
+			fmt.Fprintf(session.Out, "%s%s\n\n%s", clearScreen, heading, highlightChanges(output))
+		} else {
+			fmt.Fprintf(session.Out, "%s\n%s\n", heading, output)
+		}
+
+		select {
+		case <-watchCtx.Done():
+			session.Printf("\nStopped watching\n")
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// parseInterval reads a watch interval given in seconds or as a duration like 1m30s
+func parseInterval(arg string) (time.Duration, bool) {
+	interval, err := time.ParseDuration(arg)
+	if err != nil {
+		seconds, err := strconv.Atoi(arg)
+		if err != nil {
+			return defaultWatchInterval, false
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval, true
+}
+
+// highlightChanges colours added rows green and removed rows red
+func highlightChanges(rendered string) string {
+	lines := strings.Split(rendered, "\n")
+	for i, line := range lines {
+		match := watchedRow.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		color := green
+		if match[2] == diff.Removed || match[3] == diff.Removed {
+			color = red
+		}
+		lines[i] = color + line + resetColor
+	}
+	return strings.Join(lines, "\n")
+}
+
+func watchHelp() string {
+	return "Rerun a query on the current host every INTERVAL (seconds or a duration like 1m, default 10s) " +
+		"and redraw its results, marking rows added or removed since the last run, until Ctrl-C"
+}
+
+func watchSuggest(session *session.Session, cmdline string) []prompt.Suggest {
+	args := strings.Split(cmdline, " ")
+	if len(args) == 2 {
+		return []prompt.Suggest{
+			prompt.Suggest{Text: "5s", Description: "Run the query every 5 seconds"},
+			prompt.Suggest{Text: "30s", Description: "Run the query every 30 seconds"},
+			prompt.Suggest{Text: "1m", Description: "Run the query every minute"},
+		}
+	}
+	return querySuggest(session, cmdline)
+}
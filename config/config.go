@@ -3,8 +3,18 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path"
 	"strings"
+	"time"
+
+	"github.com/AbGuthrie/goquery/v2/pager"
+	"github.com/AbGuthrie/goquery/v2/printers"
 )
 
 // Alias is the struct used to allow abstracted commands
@@ -21,16 +31,79 @@ type Config struct {
 	Experimental bool             `json:"experimental"`
 	PrintMode    PrintModeEnum    `json:"printMode"`
 	Aliases      map[string]Alias `json:"aliases"`
+	// CommandTimeout is the number of seconds a command may run before it
+	// is cancelled, zero means commands only stop when interrupted
+	CommandTimeout int `json:"commandTimeout"`
+	// PrintWidth is how many columns the pretty print mode may use, zero means
+	// the width of the terminal
+	PrintWidth int `json:"printWidth"`
+	// PrettyOverflow is how the pretty print mode fits tables wider than
+	// PrintWidth, "truncate" (the default) or "wrap". Tables that cannot fit
+	// either way are printed in line mode.
+	PrettyOverflow string `json:"prettyOverflow"`
+	// Pager is when results are shown in the built in pager, "auto" (the
+	// default) when they don't fit on screen, "always" or "never"
+	Pager string `json:"pager"`
+	// DisableRemoteCompletion stops cd and ls suggesting paths on the remote
+	// host, which takes a query per directory, for backends where that is slow
+	DisableRemoteCompletion bool `json:"disableRemoteCompletion"`
+	// RemoteCompletionTTL is how many seconds directory listings fetched for
+	// completion are reused before being fetched again, zero means
+	// DefaultRemoteCompletionTTL
+	RemoteCompletionTTL int `json:"remoteCompletionTTL"`
+	// HostGroups maps a group name to the UUIDs of its member hosts
+	HostGroups map[string][]string `json:"hostGroups"`
+	// APISettings holds backend specific settings keyed by driver name
+	APISettings map[string]json.RawMessage `json:"apiSettings"`
+
+	// filePath is where the config was loaded from, used to persist changes
+	filePath string
+}
+
+// DefaultRemoteCompletionTTL is how many seconds directory listings fetched for
+// completion are reused when RemoteCompletionTTL isn't set
+const DefaultRemoteCompletionTTL = 60
+
+// DefaultPath returns the user's ~/.goquery/config.json if it exists,
+// otherwise the system wide /var/goquery/config.json
+func DefaultPath() string {
+	usr, err := user.Current()
+	if err == nil {
+		configPath := path.Join(usr.HomeDir, ".goquery/config.json")
+		if _, err := os.Stat(configPath); err == nil {
+			return configPath
+		}
+	}
+	return "/var/goquery/config.json"
+}
+
+// Load reads and parses the config file at filePath. The path is remembered so
+// changes can later be written back with UpdateFile.
+func Load(filePath string) (Config, error) {
+	loaded := Config{}
+	configBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return loaded, fmt.Errorf("Unable to read config file: %s", err)
+	}
+	if err := json.Unmarshal(configBytes, &loaded); err != nil {
+		return loaded, fmt.Errorf("Unable to parse config file %s: %s", filePath, err)
+	}
+	loaded.SetFilePath(filePath)
+	return loaded, nil
 }
 
-// PrintModeEnum is a type to ensure SetPrintMode recieves a valid enum
+// PrintModeEnum is the name of a print mode registered with the printers package
 type PrintModeEnum string
 
-// PrintModeEnum constants enum
+// PrintModeEnum constants for the built in print modes
 const (
-	PrintJSON   PrintModeEnum = "json"
-	PrintLine   PrintModeEnum = "line"
-	PrintPretty PrintModeEnum = "pretty"
+	PrintJSON      PrintModeEnum = "json"
+	PrintLine      PrintModeEnum = "line"
+	PrintPretty    PrintModeEnum = "pretty"
+	PrintCSV       PrintModeEnum = "csv"
+	PrintTSV       PrintModeEnum = "tsv"
+	PrintJSONLines PrintModeEnum = "jsonl"
+	PrintMarkdown  PrintModeEnum = "markdown"
 )
 
 // Validate is responsible for filtering incorrect aliases configured, and printing the state of debug modes
@@ -38,11 +111,11 @@ func (config *Config) Validate() {
 	validAliases := map[string]Alias{}
 	for aliasName, alias := range config.Aliases {
 		if len(strings.Fields(aliasName)) > 1 {
-			fmt.Printf("Aliases error: Name '%s' must not contain whitespace\n", aliasName)
+			fmt.Fprintf(os.Stderr, "Aliases error: Name '%s' must not contain whitespace\n", aliasName)
 			continue
 		}
 		if AliasIsCyclic(alias, config.Aliases) {
-			fmt.Printf("Alias error: '%s' creates an infinite loop\n", aliasName)
+			fmt.Fprintf(os.Stderr, "Alias error: '%s' creates an infinite loop\n", aliasName)
 			continue
 		}
 		validAliases[aliasName] = Alias{
@@ -53,12 +126,92 @@ func (config *Config) Validate() {
 	}
 	config.Aliases = validAliases
 
+	if _, err := printers.Get(string(config.PrintMode)); err != nil {
+		if config.PrintMode != "" {
+			fmt.Fprintf(os.Stderr, "Print mode error: %s, using '%s'\n", err, printers.DefaultMode)
+		}
+		config.PrintMode = PrintModeEnum(printers.DefaultMode)
+	}
+
+	if config.PrintWidth < 0 {
+		fmt.Fprintf(os.Stderr, "Print width error: %d must not be negative, using the terminal width\n", config.PrintWidth)
+		config.PrintWidth = 0
+	}
+	if config.PrettyOverflow != printers.OverflowTruncate && config.PrettyOverflow != printers.OverflowWrap {
+		if config.PrettyOverflow != "" {
+			fmt.Fprintf(os.Stderr, "Pretty overflow error: '%s' must be '%s' or '%s', using '%s'\n", config.PrettyOverflow,
+				printers.OverflowTruncate, printers.OverflowWrap, printers.OverflowTruncate)
+		}
+		config.PrettyOverflow = printers.OverflowTruncate
+	}
+
+	if config.Pager != pager.Auto && config.Pager != pager.Always && config.Pager != pager.Never {
+		if config.Pager != "" {
+			fmt.Fprintf(os.Stderr, "Pager error: '%s' must be '%s', '%s' or '%s', using '%s'\n", config.Pager,
+				pager.Auto, pager.Always, pager.Never, pager.Auto)
+		}
+		config.Pager = pager.Auto
+	}
+
+	if config.RemoteCompletionTTL < 0 {
+		fmt.Fprintf(os.Stderr, "Remote completion TTL error: %d must not be negative, using %d\n", config.RemoteCompletionTTL,
+			DefaultRemoteCompletionTTL)
+		config.RemoteCompletionTTL = 0
+	}
+
 	if config.DebugEnabled {
-		fmt.Println("Debug mode on")
-		fmt.Printf("Initialized with print mode '%s'\n", config.PrintMode)
-		fmt.Printf("Loaded %d alias(es)\n", len(config.Aliases))
-		fmt.Println("")
+		fmt.Fprintln(os.Stderr, "Debug mode on")
+		fmt.Fprintf(os.Stderr, "Initialized with print mode '%s'\n", config.PrintMode)
+		fmt.Fprintf(os.Stderr, "Loaded %d alias(es)\n", len(config.Aliases))
+		fmt.Fprintf(os.Stderr, "Loaded %d host group(s)\n", len(config.HostGroups))
+		if config.CommandTimeout > 0 {
+			fmt.Fprintf(os.Stderr, "Commands time out after %d second(s)\n", config.CommandTimeout)
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// CommandContext returns a context for running a single command, bounded by
+// CommandTimeout if one is configured
+func (config *Config) CommandContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if config.CommandTimeout <= 0 {
+		return context.WithCancel(parent)
 	}
+	return context.WithTimeout(parent, time.Duration(config.CommandTimeout)*time.Second)
+}
+
+// SetFilePath records the file the config was loaded from so changes made in
+// the shell, like host groups, can be written back to it
+func (config *Config) SetFilePath(filePath string) {
+	config.filePath = filePath
+}
+
+// UpdateFile sets a single top level key in the config file to value, leaving the
+// rest of the file untouched
+func (config *Config) UpdateFile(key string, value interface{}) error {
+	if config.filePath == "" {
+		return fmt.Errorf("Config was not loaded from a file")
+	}
+	fileContents := map[string]json.RawMessage{}
+	configBytes, err := ioutil.ReadFile(config.filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(configBytes) != 0 {
+		if err := json.Unmarshal(configBytes, &fileContents); err != nil {
+			return fmt.Errorf("Unable to parse config file %s: %s", config.filePath, err)
+		}
+	}
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	fileContents[key] = encodedValue
+	configBytes, err = json.MarshalIndent(fileContents, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(config.filePath, append(configBytes, '\n'), 0644)
 }
 
 // SetPrintMode assigns .PrintMode on the current config struct
@@ -66,6 +219,11 @@ func (config *Config) SetPrintMode(printMode PrintModeEnum) {
 	config.PrintMode = printMode
 }
 
+// PrintOptions returns the settings printers take into account
+func (config *Config) PrintOptions() printers.Options {
+	return printers.Options{Width: config.PrintWidth, Overflow: config.PrettyOverflow}
+}
+
 // AddAlias adds registers a new alias in the config
 func (config *Config) AddAlias(name, command string) error {
 	if len(strings.Fields(name)) > 1 {
@@ -0,0 +1,238 @@
+// Package diff compares two sets of query results, such as the same query run on
+// a compromised host and a known good peer, reporting the rows only one of them has
+// and the rows whose values changed.
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+// ChangeColumn is the column of a diff saying how each row differs
+const ChangeColumn = "change"
+
+// Kinds of difference reported in ChangeColumn
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Summary counts the rows of each kind of difference
+type Summary struct {
+	Added     int
+	Removed   int
+	Changed   int
+	Unchanged int
+}
+
+func (summary Summary) String() string {
+	return fmt.Sprintf("%d added, %d removed, %d changed, %d unchanged",
+		summary.Added, summary.Removed, summary.Changed, summary.Unchanged)
+}
+
+// Results compares before with after. Rows are matched by the values of the key
+// columns, which both must have. Without keys, rows are matched by every column
+// both have, so rows can only be added or removed, and if they share no columns
+// no rows match. The differences are returned with a ChangeColumn first and the
+// values that changed shown as "old -> new". Rows sharing a key are matched in the
+// order they appear. Only columns both results have are compared, so columns one
+// side lacks, as from different osquery versions, don't make every row differ.
+// Column names are compared case insensitively.
+func Results(before, after models.Results, keys []string) (models.Results, Summary, error) {
+	columns := unionColumns(before.Columns, after.Columns)
+	before, after = renameColumns(before, columns), renameColumns(after, columns)
+	shared := sharedColumns(before, after)
+	summary := Summary{}
+
+	// A key only one side has would give all of the other side's rows the same key
+	keyColumns := make([]string, len(keys))
+	for i, key := range keys {
+		column, ok := findColumn(shared, key)
+		if !ok {
+			return models.Results{}, summary, fmt.Errorf("No column named %s in both results to match rows by, "+
+				"shared columns are: %s", key, strings.Join(shared, ", "))
+		}
+		keyColumns[i] = column
+	}
+	if len(keyColumns) == 0 {
+		keyColumns = shared
+	}
+
+	// Group after's rows by key, keeping their order so duplicates pair up in turn.
+	// With nothing to match on every row would have the same empty key.
+	unmatched := map[string][]int{}
+	if len(keyColumns) > 0 {
+		for i, row := range after.Rows {
+			key := rowKey(row, keyColumns)
+			unmatched[key] = append(unmatched[key], i)
+		}
+	}
+	matched := make([]bool, after.Len())
+
+	// Keys first, so the rows of a diff line up with what they are matched on
+	diffColumns := append([]string{ChangeColumn}, keyColumns...)
+	for _, column := range columns {
+		if _, isKey := findColumn(keyColumns, column); !isKey {
+			diffColumns = append(diffColumns, column)
+		}
+	}
+	differences := models.Results{Columns: diffColumns, Rows: models.Rows{}}
+
+	for _, row := range before.Rows {
+		key := rowKey(row, keyColumns)
+		candidates := unmatched[key]
+		if len(candidates) == 0 {
+			differences.Rows = append(differences.Rows, changeRow(Removed, row, columns))
+			summary.Removed++
+			continue
+		}
+		unmatched[key] = candidates[1:]
+		matched[candidates[0]] = true
+
+		afterRow := after.Rows[candidates[0]]
+		changedRow := map[string]string{ChangeColumn: Changed}
+		changed := false
+		for _, column := range columns {
+			if _, ok := findColumn(shared, column); !ok {
+				changedRow[column] = row[column] + afterRow[column]
+				continue
+			}
+			if row[column] == afterRow[column] {
+				changedRow[column] = row[column]
+				continue
+			}
+			changed = true
+			changedRow[column] = row[column] + " -> " + afterRow[column]
+		}
+		if changed {
+			differences.Rows = append(differences.Rows, changedRow)
+			summary.Changed++
+		} else {
+			summary.Unchanged++
+		}
+	}
+	for i, row := range after.Rows {
+		if !matched[i] {
+			differences.Rows = append(differences.Rows, changeRow(Added, row, columns))
+			summary.Added++
+		}
+	}
+	return differences, summary, nil
+}
+
+// Annotate returns every row of after, in order, followed by the rows of before
+// that after no longer has, with a ChangeColumn first marking rows that are new in
+// after as Added and those that are gone as Removed. Rows are matched by all the
+// columns both results have, and none match if they share no columns.
+func Annotate(before, after models.Results) (models.Results, Summary) {
+	columns := unionColumns(after.Columns, before.Columns)
+	before, after = renameColumns(before, columns), renameColumns(after, columns)
+	shared := sharedColumns(after, before)
+
+	remaining := map[string]int{}
+	for _, row := range before.Rows {
+		remaining[rowKey(row, shared)]++
+	}
+	annotated := models.Results{Columns: append([]string{ChangeColumn}, columns...), Rows: models.Rows{}}
+	summary := Summary{}
+	for _, row := range after.Rows {
+		key := rowKey(row, shared)
+		if len(shared) > 0 && remaining[key] > 0 {
+			remaining[key]--
+			annotated.Rows = append(annotated.Rows, changeRow("", row, columns))
+			summary.Unchanged++
+			continue
+		}
+		annotated.Rows = append(annotated.Rows, changeRow(Added, row, columns))
+		summary.Added++
+	}
+	for _, row := range before.Rows {
+		key := rowKey(row, shared)
+		if remaining[key] > 0 {
+			remaining[key]--
+			annotated.Rows = append(annotated.Rows, changeRow(Removed, row, columns))
+			summary.Removed++
+		}
+	}
+	return annotated, summary
+}
+
+func changeRow(change string, row map[string]string, columns []string) map[string]string {
+	diffRow := map[string]string{ChangeColumn: change}
+	for _, column := range columns {
+		diffRow[column] = row[column]
+	}
+	return diffRow
+}
+
+// rowKey joins the values of columns into a string identifying the row
+func rowKey(row map[string]string, columns []string) string {
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		// Quote values so "a,b" + "c" can't be confused with "a" + "b,c"
+		values[i] = fmt.Sprintf("%q", row[column])
+	}
+	return strings.Join(values, ",")
+}
+
+// sharedColumns returns the columns of a that b has too
+func sharedColumns(a, b models.Results) []string {
+	shared := make([]string, 0)
+	for _, column := range a.Columns {
+		if _, ok := findColumn(b.Columns, column); ok {
+			shared = append(shared, column)
+		}
+	}
+	return shared
+}
+
+// renameColumns returns results with its columns, and the keys of its rows, spelt
+// as they are in columns, so columns whose names differ only in case, like PID and
+// pid, are read as the same column
+func renameColumns(results models.Results, columns []string) models.Results {
+	renamed := models.Results{Columns: make([]string, len(results.Columns)), Rows: make(models.Rows, 0, results.Len())}
+	names := map[string]string{}
+	for i, column := range results.Columns {
+		name, ok := findColumn(columns, column)
+		if !ok {
+			name = column
+		}
+		renamed.Columns[i] = name
+		names[column] = name
+	}
+	for _, row := range results.Rows {
+		renamedRow := make(map[string]string, len(row))
+		for column, value := range row {
+			if name, ok := names[column]; ok {
+				column = name
+			}
+			renamedRow[column] = value
+		}
+		renamed.Rows = append(renamed.Rows, renamedRow)
+	}
+	return renamed
+}
+
+// unionColumns returns the columns of a followed by any only b has
+func unionColumns(a, b []string) []string {
+	columns := append([]string{}, a...)
+	for _, column := range b {
+		if _, ok := findColumn(columns, column); !ok {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// findColumn returns the column of columns matching name case insensitively
+func findColumn(columns []string, name string) (string, bool) {
+	for _, column := range columns {
+		if strings.EqualFold(column, name) {
+			return column, true
+		}
+	}
+	return "", false
+}
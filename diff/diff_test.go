@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+func TestResults(t *testing.T) {
+	before := models.Results{
+		Columns: []string{"pid", "name"},
+		Rows:    models.Rows{{"pid": "1", "name": "init"}, {"pid": "42", "name": "sshd"}, {"pid": "7", "name": "cron"}},
+	}
+	tests := []struct {
+		name  string
+		after models.Results
+		keys  []string
+		want  Summary
+	}{
+		{
+			name: "same rows",
+			after: models.Results{
+				Columns: []string{"pid", "name"},
+				Rows:    models.Rows{{"pid": "7", "name": "cron"}, {"pid": "1", "name": "init"}, {"pid": "42", "name": "sshd"}},
+			},
+			want: Summary{Unchanged: 3},
+		},
+		{
+			name: "changed by key",
+			after: models.Results{
+				Columns: []string{"pid", "name"},
+				Rows:    models.Rows{{"pid": "1", "name": "init"}, {"pid": "42", "name": "bash"}, {"pid": "8", "name": "cron"}},
+			},
+			keys: []string{"pid"},
+			want: Summary{Added: 1, Removed: 1, Changed: 1, Unchanged: 1},
+		},
+		{
+			name: "no keys",
+			after: models.Results{
+				Columns: []string{"pid", "name"},
+				Rows:    models.Rows{{"pid": "1", "name": "init"}, {"pid": "42", "name": "bash"}},
+			},
+			want: Summary{Added: 1, Removed: 2, Unchanged: 1},
+		},
+		{
+			name: "columns differing in case",
+			after: models.Results{
+				Columns: []string{"PID", "Name"},
+				Rows:    models.Rows{{"PID": "1", "Name": "init"}, {"PID": "42", "Name": "sshd"}, {"PID": "7", "Name": "cron"}},
+			},
+			keys: []string{"Pid"},
+			want: Summary{Unchanged: 3},
+		},
+		{
+			name: "column only after has",
+			after: models.Results{
+				Columns: []string{"pid", "name", "uid"},
+				Rows: models.Rows{{"pid": "1", "name": "init", "uid": "0"}, {"pid": "42", "name": "sshd", "uid": "0"},
+					{"pid": "7", "name": "cron", "uid": "0"}},
+			},
+			keys: []string{"pid"},
+			want: Summary{Unchanged: 3},
+		},
+		{
+			name: "no shared columns",
+			after: models.Results{
+				Columns: []string{"port"},
+				Rows:    models.Rows{{"port": "22"}, {"port": "80"}},
+			},
+			want: Summary{Added: 2, Removed: 3},
+		},
+		{
+			name:  "no rows after",
+			after: models.Results{},
+			want:  Summary{Removed: 3},
+		},
+	}
+	for _, test := range tests {
+		_, summary, err := Results(before, test.after, test.keys)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if summary != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, summary, test.want)
+		}
+	}
+}
+
+func TestResultsRejectsKeysNotShared(t *testing.T) {
+	before := models.Results{Columns: []string{"pid"}, Rows: models.Rows{{"pid": "1"}}}
+	after := models.Results{Columns: []string{"pid", "uid"}, Rows: models.Rows{{"pid": "1", "uid": "0"}}}
+	for _, keys := range [][]string{{"uid"}, {"missing"}, {"pid", "uid"}} {
+		if _, _, err := Results(before, after, keys); err == nil {
+			t.Errorf("Results with keys %v succeeded, want an error", keys)
+		}
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	before := models.Results{
+		Columns: []string{"port"},
+		Rows:    models.Rows{{"port": "22"}, {"port": "80"}},
+	}
+	after := models.Results{
+		Columns: []string{"PORT"},
+		Rows:    models.Rows{{"PORT": "22"}, {"PORT": "443"}},
+	}
+	annotated, summary := Annotate(before, after)
+	if want := (Summary{Added: 1, Removed: 1, Unchanged: 1}); summary != want {
+		t.Fatalf("got %s, want %s", summary, want)
+	}
+	changes := []string{}
+	for _, row := range annotated.Rows {
+		changes = append(changes, row[ChangeColumn]+":"+row["PORT"])
+	}
+	want := []string{":22", "added:443", "removed:80"}
+	for i := range want {
+		if i >= len(changes) || changes[i] != want[i] {
+			t.Fatalf("got rows %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestAnnotateWithoutSharedColumns(t *testing.T) {
+	before := models.Results{Columns: []string{"pid"}, Rows: models.Rows{{"pid": "1"}}}
+	after := models.Results{Columns: []string{"port"}, Rows: models.Rows{{"port": "22"}, {"port": "80"}}}
+	if _, summary := Annotate(before, after); summary != (Summary{Added: 2, Removed: 1}) {
+		t.Errorf("got %s, want 2 added, 1 removed", summary)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+
+	"github.com/AbGuthrie/goquery/v2"
+	"github.com/AbGuthrie/goquery/v2/api"
+	_ "github.com/AbGuthrie/goquery/v2/api/mock" // registers the "mock" driver
+	"github.com/AbGuthrie/goquery/v2/config"
+	"github.com/AbGuthrie/goquery/v2/hosts"
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+func loadUserConfig() (config.Config, error) {
+	configPath := flag.String("config", config.DefaultPath(), "Path to a config file")
+	flag.Parse()
+	return config.Load(*configPath)
+}
+
+func init() {
+	// Third party backends can register themselves so they are selectable
+	// with the apiDriver config option, just like the built ins
+	api.Register("custom", func(cfg config.Config, settings json.RawMessage) (models.GoQueryAPI, error) {
+		return myCustomAPI{}, nil
+	})
+}
+
+func main() {
+	// 1. Create goquery configuration options (aliases, print mode, debug etc.)
+	// You can load from a file or use a hardcoded config (we use a hardcoded config)
+	// on error loading from the user's home folder
+	cfg, err := loadUserConfig()
+	if err != nil {
+		fmt.Printf("Couldn't load user config because of error: %s\n", err)
+		fmt.Println("Using defaults")
+
+		cfg = config.Config{
+			PrintMode:    "pretty",
+			DebugEnabled: true,
+			APIDriver:    "mock",
+			Aliases: map[string]config.Alias{
+				".all": config.Alias{
+					Description: "Select everything from a table",
+					Command:     ".query select * from $#",
+				},
+			},
+		}
+	}
+
+	// 2. Provide something that implements the required models/GoQueryAPI interface,
+	//	  either directly or by picking a registered backend from the apiDriver config
+	//	  option (see `api/mock` for example implementation)
+	// backend := myCustomAPI{}
+	// backend, err := mock.CreateMockAPI(true)
+	if cfg.APIDriver == "" {
+		cfg.APIDriver = "mock"
+	}
+	backend, err := api.Create(cfg.APIDriver, cfg)
+	if err != nil {
+		fmt.Printf("Encountered an error starting API: %s\n", err)
+		return
+	}
+
+	// 3. Call goquery
+	goquery.Run(backend, cfg)
+}
+
+type myCustomAPI struct {
+	url url.URL
+}
+
+// Implement GoQueryAPI interface
+func (apiConfig myCustomAPI) CheckHost(uuid string) (hosts.Host, error) {
+	return hosts.Host{}, fmt.Errorf("Not implemented")
+}
+
+func (apiConfig myCustomAPI) ScheduleQuery(uuid string, query string) (string, error) {
+	return "", fmt.Errorf("Not implemented")
+}
+
+func (apiConfig myCustomAPI) FetchResults(queryToken string) (models.Rows, string, error) {
+	return models.Rows{}, "", fmt.Errorf("Not implemented")
+}
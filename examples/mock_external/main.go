@@ -1,77 +1,55 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/url"
-	"os"
-	"os/user"
-	"path"
 
 	"github.com/AbGuthrie/goquery/v2"
 	"github.com/AbGuthrie/goquery/v2/api/mock"
+	"github.com/AbGuthrie/goquery/v2/commands"
 	"github.com/AbGuthrie/goquery/v2/config"
 	"github.com/AbGuthrie/goquery/v2/hosts"
 	"github.com/AbGuthrie/goquery/v2/models"
-)
-
-func parseConfigOverride(args []string) (string, error) {
-	if len(args) == 1 {
-		return "", fmt.Errorf("No override provided")
-	}
+	"github.com/AbGuthrie/goquery/v2/printers"
+	"github.com/AbGuthrie/goquery/v2/session"
 
-	if len(args) < 3 {
-		panic("Invalid arguments provided, expecting --config 'path'")
-	}
-
-	if args[1] != "--config" {
-		panic("Invalid arguments provided, expecting --config 'path'")
-	}
+	prompt "github.com/c-bata/go-prompt"
+)
 
-	return args[2], nil
+func loadUserConfig() (config.Config, error) {
+	configPath := flag.String("config", config.DefaultPath(), "Path to a config file")
+	flag.Parse()
+	return config.Load(*configPath)
 }
 
-func findUserConfig() string {
-	configPath, err := parseConfigOverride(os.Args)
-
-	// No config file override provided, check for default in ~/goquery/config.json
-	if err != nil {
-		usr, err := user.Current()
-		if err != nil {
-			fmt.Printf("Failed to fetch user info for home directory: %s\n", err)
-		} else {
-			configPath = path.Join(usr.HomeDir, ".goquery/config.json")
-		}
-	}
+func externalExample(ctx context.Context, s *session.Session, cmdline string) error {
+	s.Printf("Greetings from an external command!\n")
+	return nil
+}
 
-	// There is no home folder config so default to system wide
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		configPath = "/var/goquery/config.json"
-	}
-	return configPath
+func externalExampleHelp() string {
+	return "Example external command from outside goquery"
 }
 
-func loadUserConfig() (config.Config, error) {
-	configPath := findUserConfig()
-	configBytes, err := ioutil.ReadFile(configPath)
-	if err != nil {
-		fmt.Printf("Unable to read config file: %s at path %s\n", err, configPath)
-	}
-	decoded := &config.Config{}
-	if err := json.Unmarshal(configBytes, &decoded); err != nil {
-		fmt.Printf("Unable to parse config file: %s at path %s\n", err, configPath)
-	}
+func externalExampleSuggest(s *session.Session, cmdline string) []prompt.Suggest {
+	return []prompt.Suggest{}
+}
 
-	return *decoded, nil
+// countPrinter is an example print mode that only reports how many rows came back
+func countPrinter(w io.Writer, results models.Results) error {
+	_, err := fmt.Fprintf(w, "%d row(s)\n", results.Len())
+	return err
 }
 
 func main() {
 	// 1. Provide something that implements the required models/GoQueryAPI interface,
 	//	  or use a supported built in (see `api/mock` for example implementation)
 	// api := myCustomAPI{}
-	// api, err := osctrl.CreateOSctrlAPI(true)	// import goquery/api/mock
-	api, err := mock.CreateMockAPI(true) // import goquery/api/osctrl
+	// api, err := osctrl.CreateOSctrlAPI(true)	// import goquery/api/osctrl
+	api, err := mock.CreateMockAPI(true) // import goquery/api/mock
 
 	if err != nil {
 		fmt.Printf("Encountered an error starting API: %s\n", err)
@@ -97,8 +75,16 @@ func main() {
 			},
 		}
 	}
+	commandMap := map[string]commands.GoQueryCommand{
+		".external": {Execute: externalExample, Help: externalExampleHelp, Suggestions: externalExampleSuggest},
+		// Commands written against the older signature can be wrapped with commands.Legacy
+		//".legacy": commands.Legacy(legacyExecute, legacyHelp, legacySuggest),
+	}
+	// Print modes are registered once, then selectable with .mode like the built ins
+	printers.Register("count", printers.PrinterFunc(countPrinter))
+
 	// 3. Call goquery
-	goquery.Run(api, cfg)
+	goquery.RunWithExternalCommands(api, cfg, commandMap)
 }
 
 type myCustomAPI struct {
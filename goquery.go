@@ -1,21 +1,27 @@
 package goquery
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/AbGuthrie/goquery/v2/commands"
 	"github.com/AbGuthrie/goquery/v2/config"
 	"github.com/AbGuthrie/goquery/v2/hosts"
 	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/pipeline"
+	"github.com/AbGuthrie/goquery/v2/session"
 	"github.com/AbGuthrie/goquery/v2/utils"
 
 	prompt "github.com/c-bata/go-prompt"
 )
 
-var apiInstance models.GoQueryAPI
-var options config.Config
+// jobPollInterval is how often scheduled queries are checked for results in the background
+const jobPollInterval = 5 * time.Second
 
 // Run is the entry point for a file impporting the goquery library to start the prompt REPL
 func RunWithExternalCommands(api models.GoQueryAPI, _config config.Config, _externalCommandMap map[string]commands.GoQueryCommand) {
@@ -27,86 +33,140 @@ func RunWithExternalCommands(api models.GoQueryAPI, _config config.Config, _exte
 
 // Run is the entry point for a file impporting the goquery library to start the prompt REPL
 func Run(api models.GoQueryAPI, _config config.Config) {
-	// Print errors/warnings with provided aliases, and print state of debug flags
-	_config.Validate()
+	RunSession(newSession(api, _config))
+}
 
-	// Set globals for executor function closure
-	options = _config
-	apiInstance = api
+// RunSession starts the prompt REPL for an existing session
+func RunSession(s *session.Session) {
+	s.Jobs.Start(context.Background(), s.API, jobPollInterval)
 
-	history, err := utils.LoadHistoryFile()
+	history, err := s.LoadHistory()
 	if err != nil {
-		fmt.Printf("Unable to load history file %s\n", err)
+		fmt.Fprintf(s.Err, "Unable to load history file %s\n", err)
 	}
 
 	p := prompt.New(
-		executor,
-		completer,
+		func(input string) { executor(s, input) },
+		func(in prompt.Document) []prompt.Suggest { return completer(s, in) },
 		prompt.OptionPrefix("goquery> "),
-		prompt.OptionLivePrefix(refreshLivePrefix),
+		prompt.OptionLivePrefix(func() (string, bool) { return refreshLivePrefix(s) }),
 		prompt.OptionTitle("goquery"),
 		prompt.OptionHistory(history),
 	)
 	p.Run()
 }
 
-func refreshLivePrefix() (string, bool) {
+// newSession validates the config and creates the session used by the entry points
+// that predate sessions. They share hosts.DefaultList so the package level hosts
+// functions keep working for embedding applications.
+func newSession(api models.GoQueryAPI, _config config.Config) *session.Session {
+	// Print errors/warnings with provided aliases, and print state of debug flags
+	_config.Validate()
+	return session.NewWithHosts(api, _config, hosts.DefaultList)
+}
+
+func refreshLivePrefix(s *session.Session) (string, bool) {
 	// Prototype for showing current connected host state in
 	// input line prefix
 	subPrefix := ""
-	currentHost, err := hosts.GetCurrentHost()
+	currentHost, err := s.Hosts.GetCurrentHost()
 	if err == nil {
 		subPrefix = " | " + currentHost.ComputerName + ":" + currentHost.CurrentDirectory
 	}
+	// Let the user know background jobs have results waiting
+	if unread := s.Jobs.Unread(); unread > 0 {
+		subPrefix += fmt.Sprintf(" [%d ready]", unread)
+	}
 	return fmt.Sprintf("goquery%s> ", subPrefix), true
 }
 
-func executor(input string) {
-	writeHistory := true
-	defer func() {
-		if !writeHistory {
-			return
-		}
-		// Write history entry
-		if err := utils.UpdateHistoryFile(input); err != nil {
-			fmt.Printf("Failed to write history file: %s\n", err)
-		}
-	}()
+func executor(s *session.Session, input string) {
+	err := Execute(s, input)
+	if err == commands.ErrExit {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(s.Err, "%s\n", err)
+	}
+
+	// Write history entry
+	if err := s.AppendHistory(input); err != nil {
+		fmt.Fprintf(s.Err, "Failed to write history file: %s\n", err)
+	}
+}
 
+// Execute runs a single line of input in a session, expanding aliases, and returns any
+// error the command failed with. commands.ErrExit is returned as is when .exit is run.
+// Output can be sent to a file by ending the line with "> FILE", or ">> FILE" to
+// append to it, and results can be filtered with pipeline stages, see the
+// pipeline package. ".let NAME = COMMAND" keeps a command's results in a variable
+// that later commands refer to as ${NAME[ROW].COLUMN}.
+func Execute(s *session.Session, input string) error {
 	// Separate command and arguments
 	input = strings.TrimSpace(input)
-	args := strings.Split(input, " ")
-	if len(args) == 0 {
-		return
+	if input == "" {
+		return nil
+	}
+	if command, filePath, appendOutput := splitRedirect(input); filePath != "" {
+		return executeRedirected(s, command, filePath, appendOutput)
+	}
+	if command, stages := pipeline.Split(input); len(stages) > 0 {
+		return executePiped(s, command, stages)
 	}
+	if name, command, ok := commands.LetAssignment(input); ok {
+		return executeLet(s, name, command)
+	}
+	args := strings.Split(input, " ")
 
 	// Lookup and run command in command map
 	if command, ok := commands.CommandMap[args[0]]; ok {
-		err := command.Execute(apiInstance, &options, input)
+		// Variables are filled in last so values can't be mistaken for pipes or redirects
+		input, err := utils.InterpolateVariables(input, s.Variable)
 		if err != nil {
-			fmt.Printf("%s: %s\n", args[0], err.Error())
+			return fmt.Errorf("%s: %s", args[0], err)
 		}
-		return
+		ctx, cancel := commandContext(s)
+		defer cancel()
+		err = command.Execute(ctx, s, input)
+		if err != nil && err != commands.ErrExit {
+			return fmt.Errorf("%s: %s", args[0], err.Error())
+		}
+		return err
 	}
 
 	// Command not found, was this command aliased?
-	alias, found := options.Aliases[args[0]]
+	alias, found := s.Config.Aliases[args[0]]
 	if !found {
-		fmt.Printf("No such command: %s\n", args[0])
-		return
+		return fmt.Errorf("No such command: %s", args[0])
 	}
 	realizedCommand, err := utils.InterpolateArguments(input, alias.Command)
 	if err != nil {
-		fmt.Printf("Alias error: %s\n", err)
-		return
+		return fmt.Errorf("Alias error: %s", err)
 	}
 
-	// Run the parsed and interpolated alias through executor again
-	writeHistory = false
-	executor(realizedCommand)
+	// Run the parsed and interpolated alias through Execute again
+	return Execute(s, realizedCommand)
+}
+
+// commandContext creates the context a single command runs under. It is cancelled
+// on ctrl C or when the configured command timeout passes, aborting in flight requests
+func commandContext(s *session.Session) (context.Context, context.CancelFunc) {
+	ctx, cancel := s.Config.CommandContext(context.Background())
+
+	ctrlcChannel := make(chan os.Signal, 1)
+	signal.Notify(ctrlcChannel, os.Interrupt)
+	go func() {
+		select {
+		case <-ctrlcChannel:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(ctrlcChannel)
+	}()
+	return ctx, cancel
 }
 
-func completer(in prompt.Document) []prompt.Suggest {
+func completer(s *session.Session, in prompt.Document) []prompt.Suggest {
 	command := strings.Split(in.CurrentLine(), " ")[0]
 	// Nothing has been typed at the prompt
 	if command == "" {
@@ -125,13 +185,13 @@ func completer(in prompt.Document) []prompt.Suggest {
 			suggestions = append(suggestions, name)
 		}
 		// Add all alias suggestions
-		for name := range options.Aliases {
+		for name := range s.Config.Aliases {
 			suggestions = append(suggestions, name)
 		}
 
 		sort.Strings(suggestions)
 		for _, suggestion := range suggestions {
-			if alias, ok := options.Aliases[suggestion]; ok {
+			if alias, ok := s.Config.Aliases[suggestion]; ok {
 				description := alias.Description
 				if len(description) == 0 {
 					description = alias.Command
@@ -144,7 +204,20 @@ func completer(in prompt.Document) []prompt.Suggest {
 		return prompt.FilterHasPrefix(prompts, command, true)
 	}
 
+	// Suggest pipeline operators when starting a new stage
+	if _, stages := pipeline.Split(in.TextBeforeCursor()); len(stages) > 0 {
+		stage := stages[len(stages)-1]
+		if strings.ContainsAny(stage, " \t") {
+			return []prompt.Suggest{}
+		}
+		prompts := []prompt.Suggest{}
+		for _, name := range pipeline.Names() {
+			prompts = append(prompts, prompt.Suggest{Text: name, Description: pipeline.Description(name)})
+		}
+		return prompt.FilterHasPrefix(prompts, stage, true)
+	}
+
 	// Call into the command to ask for further suggestions
 	commandStruct := commands.CommandMap[command]
-	return prompt.FilterHasPrefix(commandStruct.Suggestions(in.CurrentLine()), in.GetWordBeforeCursor(), true)
+	return prompt.FilterHasPrefix(commandStruct.Suggestions(s, in.CurrentLine()), in.GetWordBeforeCursor(), true)
 }
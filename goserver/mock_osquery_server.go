@@ -5,6 +5,7 @@ import (
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
@@ -14,18 +15,24 @@ import (
 	"math/rand"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/crewjam/saml/samlsp"
 )
 
 type Query struct {
-	Query    string
-	Name     string
-	Complete bool
-	Result   json.RawMessage `json:"results"`
-	Status   string          `json:"status"`
+	Query      string
+	Name       string
+	Complete   bool
+	Delivered  bool
+	Result     json.RawMessage `json:"results"`
+	Status     string          `json:"status"`
+	StatusCode int             `json:"statusCode"`
+	Message    string          `json:"message"`
 }
 
 type Host struct {
@@ -36,13 +43,35 @@ type Host struct {
 	Version        string
 }
 
+// Carve is a file archive a host is uploading in blocks
+type Carve struct {
+	SessionID      string
+	CarveID        string
+	RequestID      string
+	UUID           string
+	Size           int64
+	BlockSize      int64
+	BlockCount     int
+	BlocksReceived int
+	Started        time.Time
+	blocks         map[int][]byte
+}
+
 var ENROLL_SECRET string
 
 // Maps Node Key -> UUID
 var enrolledHosts map[string]Host
 
-// Maps Node Key -> Map of Query Name -> Query struct
+// Maps Node Key -> Map of Query Name -> Query struct. Queries are scheduled,
+// delivered, answered and cancelled on separate requests so the map is guarded
+// by queryMutex.
 var queryMap map[string]map[string]Query
+var queryMutex sync.Mutex
+
+// Maps Session ID -> Carve. Blocks arrive on their own requests so the map is
+// guarded by carveMutex.
+var carveMap map[string]*Carve
+var carveMutex sync.Mutex
 
 // API Request Struct
 type apiRequest struct {
@@ -120,7 +149,9 @@ func enroll(w http.ResponseWriter, r *http.Request) {
 		newHost.UUID = parsedBody.HostIdentifier
 	}
 	enrolledHosts[nodeKey] = newHost
+	queryMutex.Lock()
 	queryMap[nodeKey] = make(map[string]Query)
+	queryMutex.Unlock()
 	fmt.Printf("Enrolled a host (%s) with node_key: %s\n", enrolledHosts[nodeKey].UUID, nodeKey)
 }
 
@@ -177,6 +208,9 @@ func distributedRead(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	queryMutex.Lock()
+	defer queryMutex.Unlock()
+
 	// The check below should never fail. If it does we've really screwed up
 	renderedQueries := ""
 	if _, ok := queryMap[parsedRequest.NodeKey]; !ok {
@@ -189,6 +223,9 @@ func distributedRead(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 		renderedQueries += fmt.Sprintf("\"%s\" : %s,", name, query.Query)
+		// Once delivered the host may already be running it so it can no longer be cancelled
+		query.Delivered = true
+		queryMap[parsedRequest.NodeKey][name] = query
 	}
 
 	renderedQueries = strings.TrimRight(renderedQueries, ",")
@@ -209,6 +246,7 @@ func distributedWrite(w http.ResponseWriter, r *http.Request) {
 	type distributedResponse struct {
 		Queries  map[string]json.RawMessage `json:"queries"`
 		Statuses map[string]int             `json:"statuses"`
+		Messages map[string]string          `json:"messages"`
 		NodeKey  string                     `json:"node_key"`
 	}
 
@@ -228,12 +266,21 @@ func distributedWrite(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type responseQuery struct {
-		Rows     json.RawMessage
-		Status   string
-		SQLQuery string
+		Rows       json.RawMessage
+		Status     string
+		StatusCode int
+		Message    string
+		SQLQuery   string
 	}
+	queryMutex.Lock()
+	defer queryMutex.Unlock()
+
 	responses := make(map[string]*responseQuery)
 	for queryName, resultsRaw := range responseParsed.Queries {
+		if _, ok := queryMap[responseParsed.NodeKey][queryName]; !ok {
+			fmt.Printf("Dropping results for unknown or cancelled query %s\n", queryName)
+			continue
+		}
 		sqlQuery := queryMap[responseParsed.NodeKey][queryName].Query
 		responses[queryName] = &responseQuery{
 			SQLQuery: sqlQuery,
@@ -241,25 +288,128 @@ func distributedWrite(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	for queryName, statusCode := range responseParsed.Statuses {
+		if _, ok := queryMap[responseParsed.NodeKey][queryName]; !ok {
+			continue
+		}
+		response, ok := responses[queryName]
+		if !ok {
+			// Failed queries may only be reported in statuses
+			response = &responseQuery{SQLQuery: queryMap[responseParsed.NodeKey][queryName].Query}
+			responses[queryName] = response
+		}
+		response.StatusCode = statusCode
 		if statusCode == 0 {
-			responses[queryName].Status = "Complete"
+			response.Status = "Complete"
 		} else {
-			responses[queryName].Status = fmt.Sprintf("Status Code %d", statusCode)
+			response.Status = "Failed"
+			response.Message = responseParsed.Messages[queryName]
 		}
 	}
 
 	for queryName, response := range responses {
 		queryMap[responseParsed.NodeKey][queryName] = Query{
-			Query:    response.SQLQuery,
-			Name:     queryName,
-			Complete: true,
-			Result:   response.Rows,
-			Status:   response.Status,
+			Query:      response.SQLQuery,
+			Name:       queryName,
+			Complete:   true,
+			Result:     response.Rows,
+			Status:     response.Status,
+			StatusCode: response.StatusCode,
+			Message:    response.Message,
 		}
 		fmt.Printf("Received and set query results for %s\n", queryName)
 	}
 }
 
+func carveInit(w http.ResponseWriter, r *http.Request) {
+	type carveInitBody struct {
+		BlockCount int    `json:"block_count"`
+		BlockSize  int64  `json:"block_size"`
+		CarveSize  int64  `json:"carve_size"`
+		CarveID    string `json:"carve_id"`
+		RequestID  string `json:"request_id"`
+		NodeKey    string `json:"node_key"`
+	}
+
+	jsonBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("Could not read body: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	parsedBody := carveInitBody{}
+	if err := json.Unmarshal(jsonBytes, &parsedBody); err != nil {
+		fmt.Printf("Could not parse body: %s\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	host, ok := enrolledHosts[parsedBody.NodeKey]
+	if !ok {
+		fmt.Fprintf(w, "{\"node_invalid\" : true}")
+		fmt.Printf("The host starting a carve is not enrolled\n")
+		return
+	}
+
+	carve := &Carve{
+		SessionID:  randomString(32),
+		CarveID:    parsedBody.CarveID,
+		RequestID:  parsedBody.RequestID,
+		UUID:       host.UUID,
+		Size:       parsedBody.CarveSize,
+		BlockSize:  parsedBody.BlockSize,
+		BlockCount: parsedBody.BlockCount,
+		Started:    time.Now(),
+		blocks:     make(map[int][]byte),
+	}
+	carveMutex.Lock()
+	carveMap[carve.SessionID] = carve
+	carveMutex.Unlock()
+	fmt.Printf("Started carve %s of %d blocks for %s\n", carve.CarveID, carve.BlockCount, carve.UUID)
+	fmt.Fprintf(w, "{\"session_id\" : \"%s\", \"success\" : true}", carve.SessionID)
+}
+
+func carveBlock(w http.ResponseWriter, r *http.Request) {
+	type carveBlockBody struct {
+		BlockID   int    `json:"block_id"`
+		SessionID string `json:"session_id"`
+		RequestID string `json:"request_id"`
+		Data      string `json:"data"`
+	}
+
+	jsonBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("Could not read body: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	parsedBody := carveBlockBody{}
+	if err := json.Unmarshal(jsonBytes, &parsedBody); err != nil {
+		fmt.Printf("Could not parse body: %s\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(parsedBody.Data)
+	if err != nil {
+		fmt.Printf("Could not decode carve block: %s\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	carveMutex.Lock()
+	defer carveMutex.Unlock()
+	carve, ok := carveMap[parsedBody.SessionID]
+	if !ok || parsedBody.BlockID < 0 || parsedBody.BlockID >= carve.BlockCount {
+		fmt.Printf("Dropping block %d for unknown carve session %s\n", parsedBody.BlockID, parsedBody.SessionID)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if _, ok := carve.blocks[parsedBody.BlockID]; !ok {
+		carve.BlocksReceived++
+	}
+	carve.blocks[parsedBody.BlockID] = data
+	fmt.Fprintf(w, "{\"success\" : true}")
+}
+
 // End osquery API endpoints
 
 func checkHostExists(requestedUUID string) (string, error) {
@@ -311,7 +461,9 @@ func scheduleQuery(w http.ResponseWriter, r *http.Request) {
 		Status: "Pending",
 	}
 
+	queryMutex.Lock()
 	queryMap[nodeKey][query.Name] = query
+	queryMutex.Unlock()
 	fmt.Fprintf(w, "{\"queryName\" : \"%s\"}", query.Name)
 }
 
@@ -321,6 +473,8 @@ func fetchResults(w http.ResponseWriter, r *http.Request) {
 	// Yes I know this is really slow. For testing it should be fine
 	// but I will fix this architecture later if needed
 	// The real solution will be to use a better backing store like postgres
+	queryMutex.Lock()
+	defer queryMutex.Unlock()
 	for _, queries := range queryMap {
 		if query, ok := queries[queryName]; ok {
 			bytes, err := json.MarshalIndent(&query, "", "\t")
@@ -336,6 +490,76 @@ func fetchResults(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotFound)
 }
 
+func cancelQuery(w http.ResponseWriter, r *http.Request) {
+	queryName := r.FormValue("queryName")
+	fmt.Printf("CancelQuery call for: %s\n", queryName)
+	queryMutex.Lock()
+	defer queryMutex.Unlock()
+	for _, queries := range queryMap {
+		if query, ok := queries[queryName]; ok {
+			if query.Complete || query.Delivered {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			delete(queries, queryName)
+			fmt.Fprintf(w, "{\"queryName\" : \"%s\"}", queryName)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func listCarves(w http.ResponseWriter, r *http.Request) {
+	uuid := r.FormValue("uuid")
+	fmt.Printf("ListCarves call for: %s\n", uuid)
+	if _, err := checkHostExists(uuid); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	carveMutex.Lock()
+	carves := make([]Carve, 0)
+	for _, carve := range carveMap {
+		if carve.UUID == uuid {
+			carves = append(carves, *carve)
+		}
+	}
+	carveMutex.Unlock()
+	sort.Slice(carves, func(i, j int) bool {
+		return carves[i].Started.Before(carves[j].Started)
+	})
+
+	renderedCarves, err := json.Marshal(carves)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(w, "%s", renderedCarves)
+}
+
+func fetchCarveBlock(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.FormValue("carveID")
+	blockID, err := strconv.Atoi(r.FormValue("block"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	carveMutex.Lock()
+	var data []byte
+	ok := false
+	if carve, carveOk := carveMap[sessionID]; carveOk {
+		data, ok = carve.blocks[blockID]
+	}
+	carveMutex.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+}
+
 // End goquery APIs
 
 func doPut(url string, metadata string) error {
@@ -355,6 +579,7 @@ func main() {
 	enableSSO := true
 	enrolledHosts = make(map[string]Host)
 	queryMap = make(map[string]map[string]Query)
+	carveMap = make(map[string]*Carve)
 
 	// Set up flags for certs
 	serverCrt := flag.String("server_cert", "certs/example_server.crt", "Location of a certificate to use")
@@ -371,6 +596,8 @@ func main() {
 	http.HandleFunc("/log", log)
 	http.HandleFunc("/distributedRead", distributedRead)
 	http.HandleFunc("/distributedWrite", distributedWrite)
+	http.HandleFunc("/carve_init", carveInit)
+	http.HandleFunc("/carve_block", carveBlock)
 
 	// goquery Endpoints
 	if enableSSO {
@@ -423,15 +650,24 @@ func main() {
 		ch := http.HandlerFunc(checkHost)
 		sq := http.HandlerFunc(scheduleQuery)
 		fr := http.HandlerFunc(fetchResults)
+		cq := http.HandlerFunc(cancelQuery)
+		lc := http.HandlerFunc(listCarves)
+		fc := http.HandlerFunc(fetchCarveBlock)
 
 		http.Handle("/checkHost", samlSP.RequireAccount(ch))
 		http.Handle("/scheduleQuery", samlSP.RequireAccount(sq))
 		http.Handle("/fetchResults", samlSP.RequireAccount(fr))
+		http.Handle("/cancelQuery", samlSP.RequireAccount(cq))
+		http.Handle("/listCarves", samlSP.RequireAccount(lc))
+		http.Handle("/fetchCarveBlock", samlSP.RequireAccount(fc))
 		http.Handle("/saml/", samlSP)
 	} else {
 		http.HandleFunc("/checkHost", checkHost)
 		http.HandleFunc("/scheduleQuery", scheduleQuery)
 		http.HandleFunc("/fetchResults", fetchResults)
+		http.HandleFunc("/cancelQuery", cancelQuery)
+		http.HandleFunc("/listCarves", listCarves)
+		http.HandleFunc("/fetchCarveBlock", fetchCarveBlock)
 	}
 	fmt.Printf("Starting test goquery/osquery backend...\n")
 	fmt.Printf("Server Cert Path: %s\n", *serverCrt)
@@ -0,0 +1,244 @@
+package hosts
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// LoadGroups replaces the known host groups, typically with those from the config file
+func (list *List) LoadGroups(newGroups map[string][]string) {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	list.groups = map[string][]string{}
+	for name, members := range newGroups {
+		list.groups[name] = append([]string{}, members...)
+	}
+}
+
+// GetGroups returns a copy of all host groups
+func (list *List) GetGroups() map[string][]string {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	snapshot := map[string][]string{}
+	for name, members := range list.groups {
+		snapshot[name] = append([]string{}, members...)
+	}
+	return snapshot
+}
+
+// GroupNames returns the sorted names of all host groups
+func (list *List) GroupNames() []string {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	names := make([]string, 0, len(list.groups))
+	for name := range list.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CreateGroup adds a new empty host group
+func (list *List) CreateGroup(name string) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if name == "" || strings.ContainsAny(name, " ,=*?[") {
+		return fmt.Errorf("Group name must not be empty or contain spaces, commas, '=' or glob characters")
+	}
+	if _, exists := list.groups[name]; exists {
+		return fmt.Errorf("Group '%s' already exists", name)
+	}
+	list.groups[name] = []string{}
+	return nil
+}
+
+// DeleteGroup removes a host group
+func (list *List) DeleteGroup(name string) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if _, exists := list.groups[name]; !exists {
+		return fmt.Errorf("Group '%s' not found", name)
+	}
+	delete(list.groups, name)
+	return nil
+}
+
+// AddToGroup adds host UUIDs to a group, ignoring any that are already members
+func (list *List) AddToGroup(name string, uuids ...string) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	members, exists := list.groups[name]
+	if !exists {
+		return fmt.Errorf("Group '%s' not found", name)
+	}
+	for _, uuid := range uuids {
+		if !containsString(members, uuid) {
+			members = append(members, uuid)
+		}
+	}
+	list.groups[name] = members
+	return nil
+}
+
+// RemoveFromGroup removes a host UUID from a group
+func (list *List) RemoveFromGroup(name, uuid string) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	members, exists := list.groups[name]
+	if !exists {
+		return fmt.Errorf("Group '%s' not found", name)
+	}
+	for i, member := range members {
+		if member == uuid {
+			list.groups[name] = append(members[:i], members[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("Host %s is not a member of group '%s'", uuid, name)
+}
+
+// Select resolves a selector to the connected hosts it matches. A selector is a comma
+// separated list of terms and matches the union of its terms. Each term is one of:
+//
+//	key=value  match a host attribute (platform, name, uuid, version), value may be a glob
+//	           and platform=linux also matches every Linux distribution
+//	group      every connected member of a named group
+//	uuid       the connected host with that UUID
+//	glob       connected hosts whose computer name matches, e.g. build-*
+func (list *List) Select(selector string) ([]Host, error) {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if strings.TrimSpace(selector) == "" {
+		return []Host{}, fmt.Errorf("Host selector must not be empty")
+	}
+
+	matched := make([]bool, len(list.connectedHosts))
+	for _, term := range strings.Split(selector, ",") {
+		matcher, err := list.selectorTermMatcher(strings.TrimSpace(term))
+		if err != nil {
+			return []Host{}, err
+		}
+		for i, host := range list.connectedHosts {
+			if matcher(host) {
+				matched[i] = true
+			}
+		}
+	}
+
+	selected := make([]Host, 0)
+	for i, host := range list.connectedHosts {
+		if matched[i] {
+			selected = append(selected, host)
+		}
+	}
+	if len(selected) == 0 {
+		return selected, fmt.Errorf("Selector '%s' matched no connected hosts", selector)
+	}
+	return selected, nil
+}
+
+// selectorTermMatcher must be called with the list mutex held as it reads groups
+func (list *List) selectorTermMatcher(term string) (func(Host) bool, error) {
+	if term == "" {
+		return nil, fmt.Errorf("Host selector contains an empty term")
+	}
+
+	if index := strings.Index(term, "="); index != -1 {
+		key, pattern := strings.ToLower(term[:index]), term[index+1:]
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("Invalid pattern in selector term '%s': %s", term, err)
+		}
+		var attribute func(Host) string
+		switch key {
+		case "platform":
+			attribute = func(host Host) string { return host.Platform }
+		case "name":
+			attribute = func(host Host) string { return host.ComputerName }
+		case "uuid":
+			attribute = func(host Host) string { return host.UUID }
+		case "version":
+			attribute = func(host Host) string { return host.Version }
+		default:
+			return nil, fmt.Errorf("Unknown selector key '%s', expected platform, name, uuid or version", key)
+		}
+		pattern = strings.ToLower(pattern)
+		return func(host Host) bool {
+			value := strings.ToLower(attribute(host))
+			if matched, _ := path.Match(pattern, value); matched {
+				return true
+			}
+			if key != "platform" {
+				return false
+			}
+			// Platforms are often reported with a version suffix, e.g. ubuntu(18.04),
+			// and users think in families (linux) rather than distributions
+			if index := strings.Index(value, "("); index != -1 {
+				if matched, _ := path.Match(pattern, value[:index]); matched {
+					return true
+				}
+			}
+			return PlatformFamily(value) == pattern
+		}, nil
+	}
+
+	if members, ok := list.groups[term]; ok {
+		return func(host Host) bool {
+			return containsString(members, host.UUID)
+		}, nil
+	}
+
+	if _, err := path.Match(term, ""); err != nil {
+		return nil, fmt.Errorf("Invalid pattern in selector term '%s': %s", term, err)
+	}
+	return func(host Host) bool {
+		if host.UUID == term {
+			return true
+		}
+		matched, _ := path.Match(term, host.ComputerName)
+		return matched
+	}, nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+var linuxPlatforms = []string{
+	"ubuntu", "debian", "centos", "rhel", "redhat", "fedora", "amzn", "arch",
+	"gentoo", "opensuse", "suse", "manjaro", "alpine", "oracle", "linux",
+}
+
+// PlatformFamily maps an osquery platform string to linux, darwin, windows or
+// freebsd. Unrecognised platforms are returned lower cased as is.
+func PlatformFamily(platform string) string {
+	platform = strings.ToLower(platform)
+	switch {
+	case strings.HasPrefix(platform, "darwin"), strings.HasPrefix(platform, "macos"):
+		return "darwin"
+	case strings.HasPrefix(platform, "windows"):
+		return "windows"
+	case strings.HasPrefix(platform, "freebsd"):
+		return "freebsd"
+	}
+	for _, linuxPlatform := range linuxPlatforms {
+		if strings.HasPrefix(platform, linuxPlatform) {
+			return "linux"
+		}
+	}
+	return platform
+}
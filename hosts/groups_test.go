@@ -0,0 +1,83 @@
+package hosts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testList(t *testing.T) *List {
+	list := NewList()
+	for _, host := range []Host{
+		{UUID: "uuid-1", ComputerName: "build-1", Platform: "ubuntu", Version: "5.2.2"},
+		{UUID: "uuid-2", ComputerName: "build-2", Platform: "ubuntu(18.04)", Version: "4.9.0"},
+		{UUID: "uuid-3", ComputerName: "WinBox", Platform: "windows", Version: "5.2.2"},
+		{UUID: "uuid-4", ComputerName: "macbook", Platform: "darwin", Version: "5.0.1"},
+	} {
+		if err := list.Register(host); err != nil {
+			t.Fatalf("Register(%s) failed: %s", host.UUID, err)
+		}
+	}
+	list.LoadGroups(map[string][]string{
+		"macs":  {"uuid-4", "uuid-missing"},
+		"empty": {},
+	})
+	return list
+}
+
+func TestSelect(t *testing.T) {
+	list := testList(t)
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"uuid-3", []string{"uuid-3"}},
+		{"build-*", []string{"uuid-1", "uuid-2"}},
+		{"build-?, macbook", []string{"uuid-1", "uuid-2", "uuid-4"}},
+		{"macs", []string{"uuid-4"}},
+		{"macs,uuid-1,macbook", []string{"uuid-1", "uuid-4"}},
+		{"platform=linux", []string{"uuid-1", "uuid-2"}},
+		{"platform=ubuntu", []string{"uuid-1", "uuid-2"}},
+		{"platform=ubuntu(18.04)", []string{"uuid-2"}},
+		{"PLATFORM=Windows", []string{"uuid-3"}},
+		{"platform=win*", []string{"uuid-3"}},
+		{"name=winbox", []string{"uuid-3"}},
+		{"name=build*", []string{"uuid-1", "uuid-2"}},
+		{"uuid=uuid-[12]", []string{"uuid-1", "uuid-2"}},
+		{"version=5.2.*", []string{"uuid-1", "uuid-3"}},
+	}
+	for _, test := range tests {
+		selected, err := list.Select(test.selector)
+		if err != nil {
+			t.Errorf("Select(%q) failed: %s", test.selector, err)
+			continue
+		}
+		uuids := make([]string, 0, len(selected))
+		for _, host := range selected {
+			uuids = append(uuids, host.UUID)
+		}
+		if !reflect.DeepEqual(uuids, test.want) {
+			t.Errorf("Select(%q) = %q, want %q", test.selector, uuids, test.want)
+		}
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	list := testList(t)
+	tests := []string{
+		"",
+		" ",
+		"build-1,",
+		"empty",
+		"platform=w",
+		"platform=lin",
+		"uuid-missing",
+		"os=linux",
+		"name=[",
+		"build-[",
+	}
+	for _, selector := range tests {
+		if selected, err := list.Select(selector); err == nil {
+			t.Errorf("Select(%q) = %v, want an error", selector, selected)
+		}
+	}
+}
@@ -6,6 +6,8 @@ package hosts
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 )
 
 type Query struct {
@@ -24,51 +26,84 @@ type Host struct {
 	Tables           []string
 }
 
+// SetCurrentDirectory changes the host's working directory, which must be an
+// absolute path ending with a separator as the host's platform writes them
 func (host *Host) SetCurrentDirectory(newDirectory string) error {
 	if len(newDirectory) == 0 {
 		return fmt.Errorf("You cannot set directory to empty string")
 	}
-	if newDirectory[len(newDirectory)-1] != '/' {
-		return fmt.Errorf("Final character of directory must be /")
+	paths := host.Paths()
+	if !paths.IsAbs(newDirectory) {
+		return fmt.Errorf("Directory %s must be an absolute path", newDirectory)
+	}
+	if !strings.HasSuffix(newDirectory, paths.Separator()) {
+		return fmt.Errorf("Final character of directory must be %s", paths.Separator())
 	}
 	host.CurrentDirectory = newDirectory
 	return nil
 }
 
-var currentHostIndex int
-var connectedHosts []Host
+// List holds the hosts a single session is connected to along with its
+// host groups. It is safe for concurrent use so background goroutines
+// (fan out queries, job polling) can read and update it.
+type List struct {
+	mutex            sync.Mutex
+	currentHostIndex int
+	connectedHosts   []Host
+	// groups maps a group name to the UUIDs of its members. Members do not
+	// need to be connected, selectors only ever resolve to connected hosts.
+	groups map[string][]string
+}
 
-func init() {
-	currentHostIndex = -1
-	connectedHosts = []Host{}
+// NewList creates an empty host list with no current host
+func NewList() *List {
+	return &List{
+		currentHostIndex: -1,
+		connectedHosts:   []Host{},
+		groups:           map[string][]string{},
+	}
 }
 
+// DefaultList is the list used by the package level functions, kept for
+// backends and commands written before sessions owned their own list
+var DefaultList = NewList()
+
 // Register is responsible for adding a host to the list
 // of established connected hosts in the host list. Also
 // update the cursor of the current connected host.
-// If a given host is already in the list, return the index
-func Register(newHost Host) error {
-	for i, host := range connectedHosts {
+// If a given host is already in the list, return the index.
+// Hosts without a current directory start at the root of their platform.
+func (list *List) Register(newHost Host) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	for i, host := range list.connectedHosts {
 		if newHost.UUID == host.UUID {
-			currentHostIndex = i
+			list.currentHostIndex = i
 			return nil
 		}
 	}
-	connectedHosts = append(connectedHosts, newHost)
-	currentHostIndex = len(connectedHosts) - 1
+	if newHost.CurrentDirectory == "" {
+		newHost.CurrentDirectory = newHost.Paths().Root()
+	}
+	list.connectedHosts = append(list.connectedHosts, newHost)
+	list.currentHostIndex = len(list.connectedHosts) - 1
 	return nil
 }
 
 // Disconnect is responsible for removing a host from the list
 // Can be called with a specific host uuid or an empty "" to
 // denote the current host the cursor is on
-func Disconnect(uuid string) error {
+func (list *List) Disconnect(uuid string) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
 	index := -1
 	if uuid == "" {
-		index = currentHostIndex
+		index = list.currentHostIndex
 	} else {
 		// find provided uuid index in list
-		for i, host := range connectedHosts {
+		for i, host := range list.connectedHosts {
 			if uuid == host.UUID {
 				index = i
 				break
@@ -79,67 +114,169 @@ func Disconnect(uuid string) error {
 		return fmt.Errorf("No active host connection with uuid %s", uuid)
 	}
 	// Remove found host index from list of connected hosts
-	connectedHosts = append(connectedHosts[:index], connectedHosts[index+1:]...)
-	currentHostIndex = -1
+	list.connectedHosts = append(list.connectedHosts[:index], list.connectedHosts[index+1:]...)
+	list.currentHostIndex = -1
 	return nil
 }
 
 // SetCurrentHost updates the current index used to fetch
 // the uuid of GetCurrentHost's call, returns the uuid
-func SetCurrentHost(targetIndex int) (string, error) {
-	if targetIndex >= 0 && targetIndex < len(connectedHosts) {
-		currentHostIndex = targetIndex
-		return connectedHosts[currentHostIndex].UUID, nil
+func (list *List) SetCurrentHost(targetIndex int) (string, error) {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if targetIndex >= 0 && targetIndex < len(list.connectedHosts) {
+		list.currentHostIndex = targetIndex
+		return list.connectedHosts[list.currentHostIndex].UUID, nil
 	}
-	return "", fmt.Errorf("Index out of range, currently connected to %d host(s)", len(connectedHosts))
+	return "", fmt.Errorf("Index out of range, currently connected to %d host(s)", len(list.connectedHosts))
 }
 
-// GetCurrentHost is a public API that returns a point to the current host structure.
-func GetCurrentHost() (Host, error) {
-	if len(connectedHosts) == 0 {
+// GetCurrentHost returns a copy of the current host structure.
+func (list *List) GetCurrentHost() (Host, error) {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if len(list.connectedHosts) == 0 {
 		return Host{}, fmt.Errorf("No active host connections")
 	}
 
-	if currentHostIndex == -1 {
+	if list.currentHostIndex == -1 {
 		return Host{}, fmt.Errorf("No host index set")
 	}
-	return connectedHosts[currentHostIndex], nil
+	return list.connectedHosts[list.currentHostIndex], nil
 }
 
-func SetCurrentHostDirectory(newDirectory string) error {
-	if len(connectedHosts) == 0 {
+// SetCurrentHostDirectory changes the working directory of the current host
+func (list *List) SetCurrentHostDirectory(newDirectory string) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if len(list.connectedHosts) == 0 {
 		return fmt.Errorf("No active host connections")
 	}
 
-	if currentHostIndex == -1 {
+	if list.currentHostIndex == -1 {
 		return fmt.Errorf("No host index set")
 	}
-	return connectedHosts[currentHostIndex].SetCurrentDirectory(newDirectory)
+	return list.connectedHosts[list.currentHostIndex].SetCurrentDirectory(newDirectory)
 }
 
-func SetHostTables(uuid string, tables []string) {
-	for index, _ := range connectedHosts {
-		if connectedHosts[index].UUID != uuid {
+// SetHostTables records the tables available on a connected host
+func (list *List) SetHostTables(uuid string, tables []string) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	for index := range list.connectedHosts {
+		if list.connectedHosts[index].UUID != uuid {
 			continue
 		}
-		connectedHosts[index].Tables = tables
-		return
+		list.connectedHosts[index].Tables = tables
+		return nil
 	}
-	panic("Setting Tables On Unconnected Host!! Something is very wrong!")
+	return fmt.Errorf("No active host connection with uuid %s", uuid)
 }
 
-func AddQueryToHost(uuid string, newQuery Query) {
-	for index, _ := range connectedHosts {
-		if connectedHosts[index].UUID != uuid {
+// AddQueryToHost appends a query to a connected host's history. Queries already
+// in the history are not added twice.
+func (list *List) AddQueryToHost(uuid string, newQuery Query) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	for index := range list.connectedHosts {
+		if list.connectedHosts[index].UUID != uuid {
+			continue
+		}
+		for _, query := range list.connectedHosts[index].QueryHistory {
+			if query.Name == newQuery.Name {
+				return nil
+			}
+		}
+		list.connectedHosts[index].QueryHistory = append(list.connectedHosts[index].QueryHistory, newQuery)
+		return nil
+	}
+	return fmt.Errorf("No active host connection with uuid %s", uuid)
+}
+
+// RemoveQueryFromHost drops a query from a host's history, for example after
+// it was cancelled. Returns an error if the host never ran the query.
+func (list *List) RemoveQueryFromHost(uuid, queryName string) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	for index := range list.connectedHosts {
+		if list.connectedHosts[index].UUID != uuid {
 			continue
 		}
-		connectedHosts[index].QueryHistory = append(connectedHosts[index].QueryHistory, newQuery)
-		return
+		history := list.connectedHosts[index].QueryHistory
+		for i, query := range history {
+			if query.Name == queryName {
+				// Snapshots from GetCurrentHosts share the history's backing array,
+				// so the history is copied rather than shifted in place
+				remaining := make([]Query, 0, len(history)-1)
+				remaining = append(remaining, history[:i]...)
+				list.connectedHosts[index].QueryHistory = append(remaining, history[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("Host %s has no query named %s", uuid, queryName)
 	}
-	panic("Query Ran On Unconnected Host!! Something is very wrong!")
+	return fmt.Errorf("No active host connection with uuid %s", uuid)
+}
+
+// GetCurrentHosts returns a copy of the current state of the connected hosts
+func (list *List) GetCurrentHosts() []Host {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	snapshot := make([]Host, len(list.connectedHosts))
+	copy(snapshot, list.connectedHosts)
+	return snapshot
+}
+
+// Register adds a host to DefaultList, see List.Register
+func Register(newHost Host) error {
+	return DefaultList.Register(newHost)
+}
+
+// Disconnect removes a host from DefaultList, see List.Disconnect
+func Disconnect(uuid string) error {
+	return DefaultList.Disconnect(uuid)
+}
+
+// SetCurrentHost updates the current host of DefaultList, see List.SetCurrentHost
+func SetCurrentHost(targetIndex int) (string, error) {
+	return DefaultList.SetCurrentHost(targetIndex)
+}
+
+// GetCurrentHost is a public API that returns a point to the current host structure.
+func GetCurrentHost() (Host, error) {
+	return DefaultList.GetCurrentHost()
+}
+
+func SetCurrentHostDirectory(newDirectory string) error {
+	return DefaultList.SetCurrentHostDirectory(newDirectory)
+}
+
+func SetHostTables(uuid string, tables []string) {
+	if err := DefaultList.SetHostTables(uuid, tables); err != nil {
+		panic("Setting Tables On Unconnected Host!! Something is very wrong!")
+	}
+}
+
+// AddQueryToHost records a query in a host's history in DefaultList. Sessions
+// record the queries they schedule themselves, so hosts connected through
+// another list are ignored rather than treated as an error.
+func AddQueryToHost(uuid string, newQuery Query) {
+	DefaultList.AddQueryToHost(uuid, newQuery)
+}
+
+// RemoveQueryFromHost drops a query from a host's history in DefaultList
+func RemoveQueryFromHost(uuid, queryName string) error {
+	return DefaultList.RemoveQueryFromHost(uuid, queryName)
 }
 
-// GetCurrentHosts is a public API that returns a the current state of the connectedHosts array
+// GetCurrentHosts is a public API that returns a copy of the current state of the connectedHosts array
 func GetCurrentHosts() []Host {
-	return connectedHosts
+	return DefaultList.GetCurrentHosts()
 }
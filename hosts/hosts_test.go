@@ -0,0 +1,43 @@
+package hosts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemoveQueryFromHost(t *testing.T) {
+	list := NewList()
+	if err := list.Register(Host{UUID: "uuid-1"}); err != nil {
+		t.Fatalf("Register failed: %s", err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if err := list.AddQueryToHost("uuid-1", Query{Name: name, SQL: "select " + name}); err != nil {
+			t.Fatalf("AddQueryToHost(%s) failed: %s", name, err)
+		}
+	}
+	snapshot := list.GetCurrentHosts()
+
+	if err := list.RemoveQueryFromHost("uuid-1", "a"); err != nil {
+		t.Fatalf("RemoveQueryFromHost failed: %s", err)
+	}
+	if err := list.RemoveQueryFromHost("uuid-1", "a"); err == nil {
+		t.Errorf("Removing a query twice should fail")
+	}
+	if err := list.RemoveQueryFromHost("uuid-2", "b"); err == nil {
+		t.Errorf("Removing a query from an unknown host should fail")
+	}
+
+	names := func(host Host) []string {
+		queryNames := []string{}
+		for _, query := range host.QueryHistory {
+			queryNames = append(queryNames, query.Name)
+		}
+		return queryNames
+	}
+	if got := names(list.GetCurrentHosts()[0]); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("History after removing a = %q, want [b c]", got)
+	}
+	if got := names(snapshot[0]); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("Snapshot taken before removing a = %q, want it unchanged", got)
+	}
+}
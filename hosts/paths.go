@@ -0,0 +1,163 @@
+package hosts
+
+import (
+	"path"
+	"strings"
+)
+
+// Paths handles file paths the way a host's platform writes them: separated by /
+// on unix like hosts, and on Windows separated by \ with a drive letter or UNC
+// share at the start and compared case insensitively
+type Paths struct {
+	Windows bool
+}
+
+// PathsFor returns the path handling for an osquery platform string
+func PathsFor(platform string) Paths {
+	return Paths{Windows: PlatformFamily(platform) == "windows"}
+}
+
+// Paths returns the path handling for the host's platform
+func (host Host) Paths() Paths {
+	return PathsFor(host.Platform)
+}
+
+// Separator returns the path separator
+func (paths Paths) Separator() string {
+	if paths.Windows {
+		return `\`
+	}
+	return "/"
+}
+
+// Root returns the directory a shell on the host starts in
+func (paths Paths) Root() string {
+	if paths.Windows {
+		return `C:\`
+	}
+	return "/"
+}
+
+// IsAbs reports whether p is an absolute path. On Windows that means starting
+// with a drive letter or UNC share, a path starting with \ is on the current drive.
+func (paths Paths) IsAbs(p string) bool {
+	if paths.Windows {
+		return windowsVolume(toSlash(p)) != ""
+	}
+	return strings.HasPrefix(p, "/")
+}
+
+// Clean returns the shortest form of p, resolving . and .. and repeated
+// separators, without a trailing separator unless p is a root. Windows paths
+// have their separators turned into \ and their drive letter upper cased.
+func (paths Paths) Clean(p string) string {
+	if !paths.Windows {
+		return path.Clean(p)
+	}
+	slashed := toSlash(p)
+	volume := windowsVolume(slashed)
+	rest := path.Clean("/" + slashed[len(volume):])
+	if volume == "" && !strings.HasPrefix(slashed, "/") {
+		rest = path.Clean(slashed)
+	}
+	return strings.Replace(volume+rest, "/", `\`, -1)
+}
+
+// Resolve makes target absolute, relative to directory when it isn't already
+func (paths Paths) Resolve(directory, target string) string {
+	switch {
+	case paths.IsAbs(target):
+	case paths.Windows && strings.HasPrefix(toSlash(target), "/"):
+		// Rooted on the current drive or share
+		target = windowsVolume(toSlash(directory)) + target
+	default:
+		target = directory + paths.Separator() + target
+	}
+	return paths.Clean(target)
+}
+
+// Directory returns p cleaned and ending with a separator, the form directories
+// are kept in
+func (paths Paths) Directory(p string) string {
+	cleaned := paths.Clean(p)
+	if strings.HasSuffix(cleaned, paths.Separator()) {
+		return cleaned
+	}
+	return cleaned + paths.Separator()
+}
+
+// Parent returns the directory containing p, cleaned
+func (paths Paths) Parent(p string) string {
+	if !paths.Windows {
+		return path.Dir(path.Clean(p))
+	}
+	slashed := toSlash(paths.Clean(p))
+	volume := windowsVolume(slashed)
+	return paths.Clean(volume + path.Dir(slashed[len(volume):]))
+}
+
+// Key returns a form of p that is the same for every way of writing the same
+// path, for comparing paths and looking them up in maps
+func (paths Paths) Key(p string) string {
+	if paths.Windows {
+		return strings.ToLower(paths.Clean(p))
+	}
+	return paths.Clean(p)
+}
+
+// Equal reports whether a and b are the same path
+func (paths Paths) Equal(a, b string) bool {
+	return paths.Key(a) == paths.Key(b)
+}
+
+// TrimDirectory returns p relative to directory, reporting false if p isn't
+// directory itself or inside it
+func (paths Paths) TrimDirectory(p, directory string) (string, bool) {
+	full, prefix := paths.Directory(p), paths.Directory(directory)
+	if len(full) < len(prefix) {
+		return "", false
+	}
+	if paths.Windows && !strings.EqualFold(full[:len(prefix)], prefix) || !paths.Windows && full[:len(prefix)] != prefix {
+		return "", false
+	}
+	return strings.TrimSuffix(full[len(prefix):], paths.Separator()), true
+}
+
+// LastSeparator returns the index of the last separator in p, or -1
+func (paths Paths) LastSeparator(p string) int {
+	if paths.Windows {
+		return strings.LastIndexAny(p, `\/`)
+	}
+	return strings.LastIndex(p, "/")
+}
+
+// Match reports whether p matches the shell pattern, which is matched case
+// insensitively on Windows
+func (paths Paths) Match(pattern, p string) (bool, error) {
+	if paths.Windows {
+		// \ is a separator rather than an escape in Windows patterns
+		return path.Match(strings.ToLower(toSlash(pattern)), strings.ToLower(toSlash(p)))
+	}
+	return path.Match(pattern, p)
+}
+
+func toSlash(p string) string {
+	return strings.Replace(p, `\`, "/", -1)
+}
+
+// windowsVolume returns the drive, as in C:, or UNC share, as in //server/share,
+// a slash separated path starts with
+func windowsVolume(slashed string) string {
+	if len(slashed) >= 2 && slashed[1] == ':' &&
+		('a' <= slashed[0] && slashed[0] <= 'z' || 'A' <= slashed[0] && slashed[0] <= 'Z') {
+		return strings.ToUpper(slashed[:1]) + ":"
+	}
+	if !strings.HasPrefix(slashed, "//") {
+		return ""
+	}
+	parts := strings.SplitN(slashed[2:], "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	return "//" + parts[0] + "/" + parts[1]
+}
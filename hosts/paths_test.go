@@ -0,0 +1,202 @@
+package hosts
+
+import "testing"
+
+var (
+	unixPaths    = Paths{}
+	windowsPaths = Paths{Windows: true}
+)
+
+func TestPathsFor(t *testing.T) {
+	tests := map[string]bool{
+		"windows":       true,
+		"Windows 10":    true,
+		"ubuntu(18.04)": false,
+		"darwin":        false,
+		"":              false,
+	}
+	for platform, want := range tests {
+		if got := PathsFor(platform).Windows; got != want {
+			t.Errorf("PathsFor(%q).Windows = %t, want %t", platform, got, want)
+		}
+	}
+}
+
+func TestIsAbs(t *testing.T) {
+	tests := []struct {
+		paths Paths
+		path  string
+		want  bool
+	}{
+		{unixPaths, "/etc", true},
+		{unixPaths, "etc", false},
+		{unixPaths, `C:\`, false},
+		{windowsPaths, `C:\Windows`, true},
+		{windowsPaths, `c:`, true},
+		{windowsPaths, `c:/Users`, true},
+		{windowsPaths, `\\server\share\file`, true},
+		{windowsPaths, `\\server`, false},
+		{windowsPaths, `\Windows`, false},
+		{windowsPaths, `Windows`, false},
+		{windowsPaths, `1:\`, false},
+	}
+	for _, test := range tests {
+		if got := test.paths.IsAbs(test.path); got != test.want {
+			t.Errorf("%+v.IsAbs(%q) = %t, want %t", test.paths, test.path, got, test.want)
+		}
+	}
+}
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		paths Paths
+		path  string
+		want  string
+	}{
+		{unixPaths, "/", "/"},
+		{unixPaths, "/etc//ssh/./", "/etc/ssh"},
+		{unixPaths, "/etc/../..", "/"},
+		{unixPaths, "etc/..", "."},
+		{windowsPaths, `c:`, `C:\`},
+		{windowsPaths, `c:\`, `C:\`},
+		{windowsPaths, `C:\Windows\\System32\.\`, `C:\Windows\System32`},
+		{windowsPaths, `C:/Windows/System32`, `C:\Windows\System32`},
+		{windowsPaths, `C:\Windows\..\..\..`, `C:\`},
+		{windowsPaths, `C:\..`, `C:\`},
+		{windowsPaths, `\\server\share\dir\..`, `\\server\share\`},
+		{windowsPaths, `\\server\share\..\..`, `\\server\share\`},
+		{windowsPaths, `\Windows\..`, `\`},
+		{windowsPaths, `Users\..\Windows`, `Windows`},
+	}
+	for _, test := range tests {
+		if got := test.paths.Clean(test.path); got != test.want {
+			t.Errorf("%+v.Clean(%q) = %q, want %q", test.paths, test.path, got, test.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		paths     Paths
+		directory string
+		target    string
+		want      string
+	}{
+		{unixPaths, "/etc/", "ssh", "/etc/ssh"},
+		{unixPaths, "/etc/", "/var/log", "/var/log"},
+		{unixPaths, "/etc/", "../..", "/"},
+		{windowsPaths, `C:\Users\`, `admin`, `C:\Users\admin`},
+		{windowsPaths, `C:\Users\`, `..\..`, `C:\`},
+		{windowsPaths, `C:\Users\`, `D:\Data`, `D:\Data`},
+		{windowsPaths, `D:\Data\`, `\Windows`, `D:\Windows`},
+		{windowsPaths, `\\server\share\dir\`, `\other`, `\\server\share\other`},
+		{windowsPaths, `\\server\share\`, `..`, `\\server\share\`},
+	}
+	for _, test := range tests {
+		if got := test.paths.Resolve(test.directory, test.target); got != test.want {
+			t.Errorf("%+v.Resolve(%q, %q) = %q, want %q", test.paths, test.directory, test.target, got, test.want)
+		}
+	}
+}
+
+func TestParent(t *testing.T) {
+	tests := []struct {
+		paths Paths
+		path  string
+		want  string
+	}{
+		{unixPaths, "/etc/ssh/", "/etc"},
+		{unixPaths, "/", "/"},
+		{windowsPaths, `C:\Windows\System32`, `C:\Windows`},
+		{windowsPaths, `C:\Windows`, `C:\`},
+		{windowsPaths, `C:\`, `C:\`},
+		{windowsPaths, `\\server\share\dir`, `\\server\share\`},
+		{windowsPaths, `\\server\share`, `\\server\share\`},
+	}
+	for _, test := range tests {
+		if got := test.paths.Parent(test.path); got != test.want {
+			t.Errorf("%+v.Parent(%q) = %q, want %q", test.paths, test.path, got, test.want)
+		}
+	}
+}
+
+func TestDirectoryAndKey(t *testing.T) {
+	tests := []struct {
+		paths     Paths
+		path      string
+		directory string
+		key       string
+	}{
+		{unixPaths, "/etc", "/etc/", "/etc"},
+		{unixPaths, "/", "/", "/"},
+		{unixPaths, "/Etc/", "/Etc/", "/Etc"},
+		{windowsPaths, `c:\Windows`, `C:\Windows\`, `c:\windows`},
+		{windowsPaths, `C:`, `C:\`, `c:\`},
+		{windowsPaths, `\\Server\Share`, `\\Server\Share\`, `\\server\share\`},
+	}
+	for _, test := range tests {
+		if got := test.paths.Directory(test.path); got != test.directory {
+			t.Errorf("%+v.Directory(%q) = %q, want %q", test.paths, test.path, got, test.directory)
+		}
+		if got := test.paths.Key(test.path); got != test.key {
+			t.Errorf("%+v.Key(%q) = %q, want %q", test.paths, test.path, got, test.key)
+		}
+	}
+	if !windowsPaths.Equal(`C:\WINDOWS\system32\`, `c:/windows/System32`) {
+		t.Errorf("Windows paths differing in case and separators should be equal")
+	}
+	if unixPaths.Equal("/etc", "/ETC") {
+		t.Errorf("Unix paths differing in case should not be equal")
+	}
+}
+
+func TestTrimDirectory(t *testing.T) {
+	tests := []struct {
+		paths     Paths
+		path      string
+		directory string
+		want      string
+		ok        bool
+	}{
+		{unixPaths, "/etc/ssh/sshd_config", "/etc", "ssh/sshd_config", true},
+		{unixPaths, "/etc", "/etc/", "", true},
+		{unixPaths, "/etcetera", "/etc", "", false},
+		{unixPaths, "/etc", "/", "etc", true},
+		{windowsPaths, `C:\Windows\System32`, `c:\windows\`, `System32`, true},
+		{windowsPaths, `C:\Windows`, `D:\`, "", false},
+		{windowsPaths, `\\server\share\a\b`, `\\SERVER\share`, `a\b`, true},
+	}
+	for _, test := range tests {
+		got, ok := test.paths.TrimDirectory(test.path, test.directory)
+		if got != test.want || ok != test.ok {
+			t.Errorf("%+v.TrimDirectory(%q, %q) = %q, %t, want %q, %t", test.paths, test.path, test.directory,
+				got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		paths   Paths
+		pattern string
+		path    string
+		want    bool
+	}{
+		{unixPaths, "/etc/*.conf", "/etc/hosts.conf", true},
+		{unixPaths, "/etc/*.conf", "/ETC/hosts.conf", false},
+		{unixPaths, `/etc/\*`, "/etc/*", true},
+		{windowsPaths, `C:\Windows\*.exe`, `c:\windows\NOTEPAD.EXE`, true},
+		{windowsPaths, `C:\Windows\*.exe`, `C:\Windows\System32\cmd.exe`, false},
+		{windowsPaths, `C:/Users/*`, `C:\Users\admin`, true},
+	}
+	for _, test := range tests {
+		got, err := test.paths.Match(test.pattern, test.path)
+		if err != nil {
+			t.Errorf("%+v.Match(%q, %q) failed: %s", test.paths, test.pattern, test.path, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%+v.Match(%q, %q) = %t, want %t", test.paths, test.pattern, test.path, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package jobs is responsible for tracking queries started with .schedule and
+// polling the backend in the background so the shell can show when their
+// results are ready without the user having to .resume each one by hand.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+// Job is a scheduled query being tracked in the background
+type Job struct {
+	Name      string
+	HostUUID  string
+	SQL       string
+	Status    models.QueryStatus
+	Scheduled time.Time
+	Finished  time.Time
+	RowCount  int
+	Read      bool
+}
+
+// Manager tracks the scheduled queries of a single session. It is safe for
+// concurrent use by commands and the background poller.
+type Manager struct {
+	mutex         sync.Mutex
+	trackedJobs   []*Job
+	pollerStarted bool
+}
+
+// NewManager creates a Manager with no tracked jobs
+func NewManager() *Manager {
+	return &Manager{trackedJobs: []*Job{}}
+}
+
+// Track starts following a newly scheduled query
+func (manager *Manager) Track(uuid, queryName, sql string) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	manager.trackedJobs = append(manager.trackedJobs, &Job{
+		Name:      queryName,
+		HostUUID:  uuid,
+		SQL:       sql,
+		Status:    models.StatusPending,
+		Scheduled: time.Now(),
+	})
+}
+
+// Remove stops tracking a job, for example after it was cancelled
+func (manager *Manager) Remove(queryName string) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	for i, job := range manager.trackedJobs {
+		if job.Name == queryName {
+			manager.trackedJobs = append(manager.trackedJobs[:i], manager.trackedJobs[i+1:]...)
+			return
+		}
+	}
+}
+
+// List returns a snapshot of all tracked jobs in the order they were scheduled
+func (manager *Manager) List() []Job {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	snapshot := make([]Job, 0, len(manager.trackedJobs))
+	for _, job := range manager.trackedJobs {
+		snapshot = append(snapshot, *job)
+	}
+	return snapshot
+}
+
+// Unread returns how many jobs have finished but not had their results viewed
+func (manager *Manager) Unread() int {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	count := 0
+	for _, job := range manager.trackedJobs {
+		if !job.Status.Pending() && !job.Read {
+			count++
+		}
+	}
+	return count
+}
+
+// MarkRead records that the results of a job have been viewed. Queries that
+// are not tracked are ignored.
+func (manager *Manager) MarkRead(queryName string) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	for _, job := range manager.trackedJobs {
+		if job.Name == queryName {
+			job.Read = true
+		}
+	}
+}
+
+// LatestFinished returns the most recently finished job that hasn't been read,
+// or the most recently finished job once all of them have been
+func (manager *Manager) LatestFinished() (Job, error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	var latest *Job
+	for _, job := range manager.trackedJobs {
+		if job.Status.Pending() {
+			continue
+		}
+		if latest == nil || latest.Read && !job.Read ||
+			latest.Read == job.Read && job.Finished.After(latest.Finished) {
+			latest = job
+		}
+	}
+	if latest == nil {
+		return Job{}, fmt.Errorf("No scheduled queries have finished yet")
+	}
+	return *latest, nil
+}
+
+// Start launches the background poller which checks pending jobs every interval
+// until ctx is done. Only the first call starts a poller.
+func (manager *Manager) Start(ctx context.Context, api models.GoQueryContextAPI, interval time.Duration) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if manager.pollerStarted {
+		return
+	}
+	manager.pollerStarted = true
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				manager.poll(ctx, api, interval)
+			}
+		}
+	}()
+}
+
+// poll checks each pending job once. The poller runs alongside commands, so it
+// never authenticates: a backend that needs to log in again fails the job, as do
+// errors that won't go away like the query being unknown, while other errors are
+// retried on the next poll.
+func (manager *Manager) poll(ctx context.Context, api models.GoQueryContextAPI, timeout time.Duration) {
+	pendingNames := make([]string, 0)
+	manager.mutex.Lock()
+	for _, job := range manager.trackedJobs {
+		if job.Status.Pending() {
+			pendingNames = append(pendingNames, job.Name)
+		}
+	}
+	manager.mutex.Unlock()
+
+	for _, queryName := range pendingNames {
+		// Don't let one slow request hold up the rest of the jobs
+		fetchCtx, cancel := context.WithTimeout(models.WithoutAuthentication(ctx), timeout)
+		results, status, err := api.FetchResultsContext(fetchCtx, queryName)
+		cancel()
+		switch {
+		case errors.Is(err, models.ErrNotAuthenticated):
+			status = models.StatusFailed(0, fmt.Sprintf("%s, use .resume %s to log in and fetch the results", err, queryName))
+		case errors.Is(err, models.ErrUnknownQuery):
+			status = models.StatusFailed(0, err.Error())
+		case err != nil || status.Pending():
+			continue
+		}
+
+		manager.mutex.Lock()
+		for _, job := range manager.trackedJobs {
+			if job.Name != queryName {
+				continue
+			}
+			job.Status = status
+			job.RowCount = len(results)
+			job.Finished = time.Now()
+		}
+		manager.mutex.Unlock()
+	}
+}
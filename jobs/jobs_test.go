@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AbGuthrie/goquery/v2/hosts"
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+// fakeAPI answers FetchResultsContext with a fixed status or error per query
+type fakeAPI struct {
+	statuses map[string]models.QueryStatus
+	errors   map[string]error
+}
+
+func (api fakeAPI) CheckHostContext(ctx context.Context, uuid string) (hosts.Host, error) {
+	return hosts.Host{UUID: uuid}, nil
+}
+
+func (api fakeAPI) ScheduleQueryContext(ctx context.Context, uuid, query string) (string, error) {
+	return "", errors.New("Not supported")
+}
+
+func (api fakeAPI) FetchResultsContext(ctx context.Context, queryName string) (models.Rows, models.QueryStatus, error) {
+	if err, ok := api.errors[queryName]; ok {
+		return models.Rows{}, models.QueryStatus{}, err
+	}
+	return models.Rows{{"one": "1"}}, api.statuses[queryName], nil
+}
+
+func TestPoll(t *testing.T) {
+	manager := NewManager()
+	for _, name := range []string{"done", "failed", "pending", "flaky", "unauthed", "unknown"} {
+		manager.Track("uuid-1", name, "select 1")
+	}
+	api := fakeAPI{
+		statuses: map[string]models.QueryStatus{
+			"done":    models.StatusComplete,
+			"failed":  models.StatusFailed(1, "no such table"),
+			"pending": models.StatusPending,
+		},
+		errors: map[string]error{
+			"flaky":    errors.New("connection reset"),
+			"unauthed": models.ErrNotAuthenticated,
+			"unknown":  models.ErrUnknownQuery,
+		},
+	}
+	manager.poll(context.Background(), api, time.Second)
+
+	want := map[string]models.QueryState{
+		"done":     models.QueryComplete,
+		"failed":   models.QueryFailed,
+		"pending":  models.QueryPending,
+		"flaky":    models.QueryPending,
+		"unauthed": models.QueryFailed,
+		"unknown":  models.QueryFailed,
+	}
+	for _, job := range manager.List() {
+		if job.Status.State != want[job.Name] {
+			t.Errorf("Job %s has status %s after polling", job.Name, job.Status)
+		}
+	}
+	if got := manager.Unread(); got != 4 {
+		t.Errorf("Unread() = %d, want 4", got)
+	}
+}
+
+func TestLatestFinished(t *testing.T) {
+	manager := NewManager()
+	if _, err := manager.LatestFinished(); err == nil {
+		t.Errorf("LatestFinished() with no jobs should fail")
+	}
+	for _, name := range []string{"first", "second", "pending"} {
+		manager.Track("uuid-1", name, "select 1")
+	}
+	finished := time.Now()
+	for i, job := range manager.trackedJobs[:2] {
+		job.Status = models.StatusFailed(1, "")
+		job.Finished = finished.Add(time.Duration(i) * time.Second)
+	}
+
+	for _, want := range []string{"second", "first", "second"} {
+		job, err := manager.LatestFinished()
+		if err != nil {
+			t.Fatalf("LatestFinished() failed: %s", err)
+		}
+		if job.Name != want {
+			t.Errorf("LatestFinished() = %s, want %s", job.Name, want)
+		}
+		manager.MarkRead(job.Name)
+	}
+	if got := manager.Unread(); got != 0 {
+		t.Errorf("Unread() after reading every finished job = %d, want 0", got)
+	}
+}
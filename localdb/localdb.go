@@ -0,0 +1,284 @@
+// Package localdb keeps the results of queries fetched in a session in an in memory
+// SQLite database, so they can be joined, grouped and aggregated locally without
+// running anything else on the hosts. Each query's results are stored as a table
+// named after the query, and tables can be given friendlier names with Alias.
+package localdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/sqlquote"
+
+	// Registers the pure Go "sqlite" database/sql driver
+	_ "modernc.org/sqlite"
+)
+
+// Table describes a set of stored results
+type Table struct {
+	Name    string
+	Aliases []string
+	Host    string
+	SQL     string
+	Rows    int
+}
+
+// DB is an in memory database of query results. The database is only opened once
+// results are first stored, so sessions that never use it pay nothing for it.
+type DB struct {
+	mutex  sync.Mutex
+	db     *sql.DB
+	tables []*Table
+}
+
+// New creates an empty database
+func New() *DB {
+	return &DB{}
+}
+
+// open lazily opens the database, the caller must hold the mutex
+func (db *DB) open() (*sql.DB, error) {
+	if db.db != nil {
+		return db.db, nil
+	}
+	opened, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("Could not open local database: %s", err)
+	}
+	// Every connection to :memory: is a separate database, so only ever use one
+	opened.SetMaxOpenConns(1)
+	db.db = opened
+	return opened, nil
+}
+
+// Store saves results as a table named after the query that returned them,
+// replacing any results previously stored for it. Values that are plain numbers
+// are stored as numbers so they compare and sort numerically.
+func (db *DB) Store(name, host, query string, results models.Results) error {
+	columns := results.Columns
+	if len(columns) == 0 {
+		// Without rows, the SELECT list is the only place columns are known from
+		columns = make([]string, 0)
+		for _, column := range models.SelectColumns(query) {
+			if column != "*" {
+				columns = append(columns, column)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("Results of %s have no columns to store", name)
+	}
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	opened, err := db.open()
+	if err != nil {
+		return err
+	}
+
+	transaction, err := opened.Begin()
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+
+	quotedColumns := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		quotedColumns[i] = sqlquote.Identifier(column)
+		placeholders[i] = "?"
+	}
+	statements := []string{
+		"DROP TABLE IF EXISTS " + sqlquote.Identifier(name),
+		// Columns have no type so values keep the type they are inserted with
+		fmt.Sprintf("CREATE TABLE %s (%s)", sqlquote.Identifier(name), strings.Join(quotedColumns, ", ")),
+	}
+	for _, statement := range statements {
+		if _, err := transaction.Exec(statement); err != nil {
+			return fmt.Errorf("Could not create table for %s: %s", name, err)
+		}
+	}
+
+	insert, err := transaction.Prepare(fmt.Sprintf("INSERT INTO %s VALUES (%s)",
+		sqlquote.Identifier(name), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	values := make([]interface{}, len(columns))
+	for _, row := range results.Rows {
+		for i, column := range columns {
+			values[i] = storedValue(row[column])
+		}
+		if _, err := insert.Exec(values...); err != nil {
+			return fmt.Errorf("Could not store results of %s: %s", name, err)
+		}
+	}
+	if err := transaction.Commit(); err != nil {
+		return err
+	}
+
+	table := db.table(name)
+	if table == nil {
+		table = &Table{Name: name}
+		db.tables = append(db.tables, table)
+	}
+	table.Host, table.SQL, table.Rows = host, query, results.Len()
+	return nil
+}
+
+// Alias makes the stored results of name also available as alias, replacing any
+// other table alias referred to
+func (db *DB) Alias(alias, name string) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	table := db.table(name)
+	if table == nil {
+		return fmt.Errorf("No stored results for %s", name)
+	}
+	if db.table(alias) != nil {
+		return fmt.Errorf("%s is the name of stored results", alias)
+	}
+	opened, err := db.open()
+	if err != nil {
+		return err
+	}
+	statements := []string{
+		"DROP VIEW IF EXISTS " + sqlquote.Identifier(alias),
+		fmt.Sprintf("CREATE VIEW %s AS SELECT * FROM %s", sqlquote.Identifier(alias), sqlquote.Identifier(name)),
+	}
+	for _, statement := range statements {
+		if _, err := opened.Exec(statement); err != nil {
+			return fmt.Errorf("Could not name results %s: %s", alias, err)
+		}
+	}
+
+	for _, existing := range db.tables {
+		existing.Aliases = removeString(existing.Aliases, alias)
+	}
+	table.Aliases = append(table.Aliases, alias)
+	return nil
+}
+
+// Query runs SQL against the stored results. Columns sharing a name, as from
+// joining tables with "select *", are numbered to keep them apart.
+func (db *DB) Query(ctx context.Context, query string) (models.Results, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	results := models.Results{Columns: []string{}, Rows: models.Rows{}}
+	opened, err := db.open()
+	if err != nil {
+		return results, err
+	}
+	rows, err := opened.QueryContext(ctx, query)
+	if err != nil {
+		return results, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return results, err
+	}
+	results.Columns = uniqueNames(columns)
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return results, err
+		}
+		row := map[string]string{}
+		for i, column := range results.Columns {
+			row[column] = formatValue(values[i])
+		}
+		results.Rows = append(results.Rows, row)
+	}
+	return results, rows.Err()
+}
+
+// Tables returns the stored results, oldest first
+func (db *DB) Tables() []Table {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	tables := make([]Table, 0, len(db.tables))
+	for _, table := range db.tables {
+		copied := *table
+		copied.Aliases = append([]string{}, table.Aliases...)
+		tables = append(tables, copied)
+	}
+	return tables
+}
+
+// table returns the stored results called name, the caller must hold the mutex
+func (db *DB) table(name string) *Table {
+	for _, table := range db.tables {
+		if table.Name == name {
+			return table
+		}
+	}
+	return nil
+}
+
+// storedValue converts a result value to a number when that loses nothing, so
+// "42" becomes 42 but "0755" and "1.50" are kept as text
+func storedValue(value string) interface{} {
+	if integer, err := strconv.ParseInt(value, 10, 64); err == nil && strconv.FormatInt(integer, 10) == value {
+		return integer
+	}
+	if float, err := strconv.ParseFloat(value, 64); err == nil && strconv.FormatFloat(float, 'f', -1, 64) == value {
+		return float
+	}
+	return value
+}
+
+func formatValue(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(typed)
+	case string:
+		return typed
+	case int64:
+		return strconv.FormatInt(typed, 10)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// uniqueNames numbers repeated names, so pid, pid becomes pid, pid_2
+func uniqueNames(names []string) []string {
+	unique := make([]string, len(names))
+	seen := map[string]bool{}
+	for i, name := range names {
+		candidate := name
+		for suffix := 2; seen[strings.ToLower(candidate)]; suffix++ {
+			candidate = fmt.Sprintf("%s_%d", name, suffix)
+		}
+		seen[strings.ToLower(candidate)] = true
+		unique[i] = candidate
+	}
+	return unique
+}
+
+func removeString(values []string, remove string) []string {
+	kept := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != remove {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"context"
+
 	"github.com/AbGuthrie/goquery/v2/hosts"
 )
 
@@ -12,3 +14,99 @@ type GoQueryAPI interface {
 	ScheduleQuery(string, string) (string, error)
 	FetchResults(string) (Rows, string, error)
 }
+
+// GoQueryContextAPI is the context aware version of GoQueryAPI. Implementations should
+// abort any in flight backend requests once the provided context is cancelled or its
+// deadline passes. Backends that only implement GoQueryAPI can be adapted with WithContext.
+type GoQueryContextAPI interface {
+	CheckHostContext(context.Context, string) (hosts.Host, error)
+	ScheduleQueryContext(context.Context, string, string) (string, error)
+	FetchResultsContext(context.Context, string) (Rows, QueryStatus, error)
+}
+
+// QueryCanceller is an optional interface for backends that can withdraw a scheduled
+// query before it is delivered to the host. Commands detect support with a type assertion
+// on Unwrap of the GoQueryContextAPI they are given.
+type QueryCanceller interface {
+	CancelQueryContext(context.Context, string) error
+}
+
+// WithContext returns a GoQueryContextAPI for the provided api. If the api already
+// implements GoQueryContextAPI it is returned as is, otherwise it is wrapped so calls
+// return as soon as the context is done and status strings are converted with
+// ParseQueryStatus. The wrapped call itself cannot be aborted and is left to finish
+// in the background.
+func WithContext(api GoQueryAPI) GoQueryContextAPI {
+	if contextAPI, ok := api.(GoQueryContextAPI); ok {
+		return contextAPI
+	}
+	return contextAdapter{api: api}
+}
+
+// Unwrap returns the backend api was made from by WithContext, so the optional
+// interfaces it implements can be detected with a type assertion
+func Unwrap(api GoQueryContextAPI) interface{} {
+	if adapter, ok := api.(contextAdapter); ok {
+		return adapter.api
+	}
+	return api
+}
+
+type contextAdapter struct {
+	api GoQueryAPI
+}
+
+func (adapter contextAdapter) CheckHostContext(ctx context.Context, uuid string) (hosts.Host, error) {
+	type checkHostResult struct {
+		host hosts.Host
+		err  error
+	}
+	done := make(chan checkHostResult, 1)
+	go func() {
+		host, err := adapter.api.CheckHost(uuid)
+		done <- checkHostResult{host, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return hosts.Host{}, ctx.Err()
+	case result := <-done:
+		return result.host, result.err
+	}
+}
+
+func (adapter contextAdapter) ScheduleQueryContext(ctx context.Context, uuid string, query string) (string, error) {
+	type scheduleQueryResult struct {
+		queryName string
+		err       error
+	}
+	done := make(chan scheduleQueryResult, 1)
+	go func() {
+		queryName, err := adapter.api.ScheduleQuery(uuid, query)
+		done <- scheduleQueryResult{queryName, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case result := <-done:
+		return result.queryName, result.err
+	}
+}
+
+func (adapter contextAdapter) FetchResultsContext(ctx context.Context, queryName string) (Rows, QueryStatus, error) {
+	type fetchResultsResult struct {
+		rows   Rows
+		status QueryStatus
+		err    error
+	}
+	done := make(chan fetchResultsResult, 1)
+	go func() {
+		rows, status, err := adapter.api.FetchResults(queryName)
+		done <- fetchResultsResult{rows, ParseQueryStatus(status), err}
+	}()
+	select {
+	case <-ctx.Done():
+		return Rows{}, QueryStatus{}, ctx.Err()
+	case result := <-done:
+		return result.rows, result.status, result.err
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AbGuthrie/goquery/v2/hosts"
+)
+
+// legacyAPI is a backend written before GoQueryContextAPI that can cancel queries
+type legacyAPI struct{}
+
+func (legacyAPI) CheckHost(uuid string) (hosts.Host, error)                      { return hosts.Host{UUID: uuid}, nil }
+func (legacyAPI) ScheduleQuery(uuid, query string) (string, error)               { return "query", nil }
+func (legacyAPI) FetchResults(queryName string) (Rows, string, error)            { return Rows{}, "Pending", nil }
+func (legacyAPI) CancelQueryContext(ctx context.Context, queryName string) error { return nil }
+
+func TestUnwrap(t *testing.T) {
+	api := WithContext(legacyAPI{})
+	if _, ok := api.(QueryCanceller); ok {
+		t.Fatalf("WithContext of a legacy backend should be wrapped")
+	}
+	if _, ok := Unwrap(api).(QueryCanceller); !ok {
+		t.Errorf("Unwrap(WithContext(api)) should implement the optional interfaces of api")
+	}
+	if _, ok := Unwrap(api).(CarveAPI); ok {
+		t.Errorf("Unwrap(WithContext(api)) should not implement interfaces api doesn't")
+	}
+
+	_, status, err := api.FetchResultsContext(context.Background(), "query")
+	if err != nil || !status.Pending() {
+		t.Errorf("FetchResultsContext through the adapter = %v, %v, want a pending status", status, err)
+	}
+}
+
+// hungAPI is a legacy backend whose calls never return
+type hungAPI struct{ block chan struct{} }
+
+func (api hungAPI) CheckHost(uuid string) (hosts.Host, error) {
+	<-api.block
+	return hosts.Host{}, nil
+}
+
+func (api hungAPI) ScheduleQuery(uuid, query string) (string, error) {
+	<-api.block
+	return "", nil
+}
+
+func (api hungAPI) FetchResults(queryName string) (Rows, string, error) {
+	<-api.block
+	return Rows{}, "", nil
+}
+
+func TestWithContextCancel(t *testing.T) {
+	backend := hungAPI{block: make(chan struct{})}
+	defer close(backend.block)
+	api := WithContext(backend)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := api.CheckHostContext(ctx, "host"); err != context.Canceled {
+		t.Errorf("CheckHostContext = %v, want %v", err, context.Canceled)
+	}
+	if _, err := api.ScheduleQueryContext(ctx, "host", "select 1"); err != context.Canceled {
+		t.Errorf("ScheduleQueryContext = %v, want %v", err, context.Canceled)
+	}
+	if _, _, err := api.FetchResultsContext(ctx, "query"); err != context.Canceled {
+		t.Errorf("FetchResultsContext = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotAuthenticated is returned by backends that have no session with their
+// server for a request made with a context from WithoutAuthentication
+var ErrNotAuthenticated = errors.New("Not authenticated with the backend")
+
+// ErrUnknownQuery is returned by backends asked for the results of a query they don't know
+var ErrUnknownQuery = errors.New("Unknown queryName")
+
+type authenticationKey struct{}
+
+// WithoutAuthentication returns a context telling backends not to authenticate, and
+// so never prompt for credentials, for requests made in the background such as
+// polling jobs or completing paths. Backends return ErrNotAuthenticated instead.
+func WithoutAuthentication(ctx context.Context) context.Context {
+	return context.WithValue(ctx, authenticationKey{}, false)
+}
+
+// AuthenticationAllowed reports whether a backend may authenticate for a request made with ctx
+func AuthenticationAllowed(ctx context.Context) bool {
+	allowed, ok := ctx.Value(authenticationKey{}).(bool)
+	return !ok || allowed
+}
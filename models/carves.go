@@ -0,0 +1,36 @@
+package models
+
+import "context"
+
+// Carve is a file archive osquery is uploading, or has uploaded, to the backend
+// after a query of the carves table with carve = 1. The archive is a tar split
+// into BlockCount blocks of BlockSize bytes, the last possibly shorter.
+type Carve struct {
+	// ID identifies the carve to the backend when fetching its blocks
+	ID string
+	// GUID is the carve_guid osquery reports for the carve
+	GUID string
+	// RequestID is the name of the distributed query that started the carve
+	RequestID      string
+	UUID           string
+	Size           int64
+	BlockSize      int64
+	BlockCount     int
+	BlocksReceived int
+}
+
+// Complete reports whether every block of the carve has been uploaded. A carve
+// without blocks hasn't been described by the host yet.
+func (carve Carve) Complete() bool {
+	return carve.BlockCount > 0 && carve.BlocksReceived >= carve.BlockCount
+}
+
+// CarveAPI is an optional interface for backends that receive osquery's file
+// carves. Commands detect support with a type assertion on Unwrap of the
+// GoQueryContextAPI they are given.
+type CarveAPI interface {
+	// ListCarvesContext returns the carves started on a host
+	ListCarvesContext(ctx context.Context, uuid string) ([]Carve, error)
+	// FetchCarveBlockContext returns the data of one block of a carve, numbered from 0
+	FetchCarveBlockContext(ctx context.Context, carveID string, block int) ([]byte, error)
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Results are query results along with the order of their columns. Rows is kept
+// as maps so existing code looking values up by column name keeps working.
+type Results struct {
+	Columns []string
+	Rows    Rows
+}
+
+// OrderedResultsAPI is implemented by backends that can report the column order
+// osquery returned results in. Results from backends that don't implement it are
+// ordered by the query's SELECT list.
+type OrderedResultsAPI interface {
+	FetchOrderedResultsContext(ctx context.Context, queryName string) (Results, QueryStatus, error)
+}
+
+// NewResults converts Rows, which have no column order, to Results with their
+// columns sorted alphabetically
+func NewResults(rows Rows) Results {
+	return Results{Columns: OrderColumns(nil, rows), Rows: rows}
+}
+
+// NewResultsForQuery converts Rows to Results with their columns in the order
+// they are selected by query, see SelectColumns
+func NewResultsForQuery(query string, rows Rows) Results {
+	return Results{Columns: OrderColumns(SelectColumns(query), rows), Rows: rows}
+}
+
+// Len returns the number of rows
+func (results Results) Len() int {
+	return len(results.Rows)
+}
+
+// OrderColumns returns every column present in rows, ordered by preferred. Column
+// names are matched case insensitively, a "*" in preferred stands for all columns
+// not named elsewhere, and columns preferred doesn't mention are sorted at the end.
+func OrderColumns(preferred []string, rows Rows) []string {
+	present := map[string]string{}
+	for _, row := range rows {
+		for column := range row {
+			present[strings.ToLower(column)] = column
+		}
+	}
+
+	named := map[string]bool{}
+	for _, name := range preferred {
+		named[strings.ToLower(name)] = true
+	}
+	remaining := func() []string {
+		columns := make([]string, 0)
+		for lower, column := range present {
+			if !named[lower] {
+				columns = append(columns, column)
+			}
+		}
+		sort.Strings(columns)
+		return columns
+	}
+
+	ordered := make([]string, 0, len(present))
+	added := map[string]bool{}
+	add := func(column string) {
+		lower := strings.ToLower(column)
+		if !added[lower] {
+			added[lower] = true
+			ordered = append(ordered, column)
+		}
+	}
+	for _, name := range preferred {
+		if name == "*" {
+			for _, column := range remaining() {
+				add(column)
+			}
+			continue
+		}
+		if column, ok := present[strings.ToLower(name)]; ok {
+			add(column)
+		}
+	}
+	for _, column := range remaining() {
+		add(column)
+	}
+	return ordered
+}
+
+// DecodeResults decodes a JSON array of objects, as osquery returns for a query,
+// keeping the column order of the objects. Values that aren't strings are kept as
+// their JSON text, and null or empty input decodes to no rows.
+func DecodeResults(data []byte) (Results, error) {
+	results := Results{Columns: []string{}, Rows: Rows{}}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return results, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	if err := expectDelimiter(decoder, '['); err != nil {
+		return results, err
+	}
+	seen := map[string]bool{}
+	for decoder.More() {
+		if err := expectDelimiter(decoder, '{'); err != nil {
+			return results, err
+		}
+		row := map[string]string{}
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return results, err
+			}
+			column, ok := token.(string)
+			if !ok {
+				return results, fmt.Errorf("Expected a column name in results, got %v", token)
+			}
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return results, err
+			}
+			value := string(raw)
+			if len(raw) > 0 && raw[0] == '"' {
+				if err := json.Unmarshal(raw, &value); err != nil {
+					return results, err
+				}
+			}
+			row[column] = value
+			if !seen[column] {
+				seen[column] = true
+				results.Columns = append(results.Columns, column)
+			}
+		}
+		if err := expectDelimiter(decoder, '}'); err != nil {
+			return results, err
+		}
+		results.Rows = append(results.Rows, row)
+	}
+	if err := expectDelimiter(decoder, ']'); err != nil {
+		return results, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return results, fmt.Errorf("Unexpected data after results")
+	}
+	return results, nil
+}
+
+func expectDelimiter(decoder *json.Decoder, delimiter json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delimiter {
+		return fmt.Errorf("Expected %v in results, got %v", delimiter, token)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeResults(t *testing.T) {
+	tests := []struct {
+		data string
+		want Results
+	}{
+		{"", Results{Columns: []string{}, Rows: Rows{}}},
+		{" null\n", Results{Columns: []string{}, Rows: Rows{}}},
+		{"[]", Results{Columns: []string{}, Rows: Rows{}}},
+		{
+			`[{"pid": "1", "name": "init"}, {"pid": "42", "name": "sshd"}]`,
+			Results{Columns: []string{"pid", "name"}, Rows: Rows{{"pid": "1", "name": "init"}, {"pid": "42", "name": "sshd"}}},
+		},
+		{
+			`[{"name": "init"}, {"pid": "42", "name": "sshd"}]`,
+			Results{Columns: []string{"name", "pid"}, Rows: Rows{{"name": "init"}, {"pid": "42", "name": "sshd"}}},
+		},
+		{
+			`[{"pid": 1, "ok": true, "parent": null, "path": "C:\\Windows \"x\""}]`,
+			Results{
+				Columns: []string{"pid", "ok", "parent", "path"},
+				Rows:    Rows{{"pid": "1", "ok": "true", "parent": "null", "path": `C:\Windows "x"`}},
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := DecodeResults([]byte(test.data))
+		if err != nil {
+			t.Errorf("DecodeResults(%q) failed: %s", test.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("DecodeResults(%q) = %v, want %v", test.data, got, test.want)
+		}
+	}
+}
+
+func TestDecodeResultsErrors(t *testing.T) {
+	tests := []string{
+		`{"pid": "1"}`,
+		`["pid"]`,
+		`[{"pid": "1"}`,
+		`[{"pid": "1"}] trailing`,
+		`[[1]]`,
+		`not json`,
+	}
+	for _, data := range tests {
+		if got, err := DecodeResults([]byte(data)); err == nil {
+			t.Errorf("DecodeResults(%q) = %v, want an error", data, got)
+		}
+	}
+}
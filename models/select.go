@@ -0,0 +1,220 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SelectColumns returns the names of the columns selected by the first top level
+// SELECT of query, in order, following SQLite's naming: aliases are used when
+// given, table qualifiers are dropped from column references, and any other
+// expression is named by its text. Wildcards are returned as "*". An empty list
+// is returned when query has no SELECT.
+func SelectColumns(query string) []string {
+	selectList, ok := topLevelSelectList(query)
+	if !ok {
+		return []string{}
+	}
+
+	columns := make([]string, 0)
+	for _, expression := range splitTopLevel(selectList, ',') {
+		expression = strings.TrimSpace(expression)
+		if expression == "" {
+			continue
+		}
+		columns = append(columns, selectColumnName(expression))
+	}
+	return columns
+}
+
+// sqlScanner walks query text tracking parenthesis depth and quoting, so callers
+// only act on characters outside strings, quoted identifiers and sub expressions
+type sqlScanner struct {
+	depth int
+	quote rune
+}
+
+// step updates the scanner with the next character and reports whether it is at
+// the top level, outside of any quotes or parentheses
+func (scanner *sqlScanner) step(char rune) bool {
+	if scanner.quote != 0 {
+		if char == scanner.quote {
+			scanner.quote = 0
+		}
+		return false
+	}
+	switch char {
+	case '\'', '"', '`':
+		scanner.quote = char
+		return false
+	case '[':
+		scanner.quote = ']'
+		return false
+	case '(':
+		scanner.depth++
+		return false
+	case ')':
+		scanner.depth--
+		return false
+	}
+	return scanner.depth == 0
+}
+
+// topLevelSelectList returns the text between the first top level SELECT keyword
+// and the FROM ending its column list, or the end of the query
+func topLevelSelectList(query string) (string, bool) {
+	words := topLevelWords(query)
+	start := -1
+	for _, word := range words {
+		lower := strings.ToLower(word.text)
+		if start == -1 {
+			if lower == "select" {
+				start = word.end
+			}
+			continue
+		}
+		if lower == "from" || lower == "where" || lower == "union" || lower == "group" ||
+			lower == "order" || lower == "limit" {
+			return trimSelectModifier(query[start:word.start]), true
+		}
+	}
+	if start == -1 {
+		return "", false
+	}
+	return trimSelectModifier(strings.TrimRight(query[start:], "; \t\n")), true
+}
+
+func trimSelectModifier(selectList string) string {
+	selectList = strings.TrimSpace(selectList)
+	for _, modifier := range []string{"distinct", "all"} {
+		if len(selectList) > len(modifier) && strings.EqualFold(selectList[:len(modifier)], modifier) &&
+			unicode.IsSpace(rune(selectList[len(modifier)])) {
+			return strings.TrimSpace(selectList[len(modifier):])
+		}
+	}
+	return selectList
+}
+
+type sqlWord struct {
+	text       string
+	start, end int
+}
+
+// topLevelWords returns the bare words of query that are outside quotes and parentheses
+func topLevelWords(query string) []sqlWord {
+	words := make([]sqlWord, 0)
+	scanner := sqlScanner{}
+	wordStart := -1
+	for index, char := range query {
+		topLevel := scanner.step(char)
+		isWordChar := topLevel && (unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_')
+		if isWordChar && wordStart == -1 {
+			wordStart = index
+		}
+		if !isWordChar && wordStart != -1 {
+			words = append(words, sqlWord{text: query[wordStart:index], start: wordStart, end: index})
+			wordStart = -1
+		}
+	}
+	if wordStart != -1 {
+		words = append(words, sqlWord{text: query[wordStart:], start: wordStart, end: len(query)})
+	}
+	return words
+}
+
+// splitTopLevel splits text on separator where it appears outside quotes and parentheses
+func splitTopLevel(text string, separator rune) []string {
+	parts := make([]string, 0)
+	scanner := sqlScanner{}
+	partStart := 0
+	for index, char := range text {
+		if scanner.step(char) && char == separator {
+			parts = append(parts, text[partStart:index])
+			partStart = index + len(string(char))
+		}
+	}
+	return append(parts, text[partStart:])
+}
+
+// selectColumnName names a single select list expression
+func selectColumnName(expression string) string {
+	// An explicit alias, "expression AS name", or an implicit one, "expression name"
+	fields := splitTopLevelFields(expression)
+	if len(fields) >= 3 && strings.EqualFold(fields[len(fields)-2], "as") {
+		return unquoteIdentifier(fields[len(fields)-1])
+	}
+	if len(fields) == 2 && isIdentifier(fields[1]) {
+		return unquoteIdentifier(fields[1])
+	}
+
+	if expression == "*" || strings.HasSuffix(expression, ".*") {
+		return "*"
+	}
+
+	// A column reference, optionally qualified with its table
+	parts := splitTopLevel(expression, '.')
+	column := strings.TrimSpace(parts[len(parts)-1])
+	if isIdentifier(column) {
+		for _, part := range parts[:len(parts)-1] {
+			if !isIdentifier(strings.TrimSpace(part)) {
+				return expression
+			}
+		}
+		return unquoteIdentifier(column)
+	}
+	return expression
+}
+
+// splitTopLevelFields splits expression on whitespace outside quotes and parentheses
+func splitTopLevelFields(expression string) []string {
+	fields := make([]string, 0)
+	scanner := sqlScanner{}
+	fieldStart := -1
+	for index, char := range expression {
+		topLevel := scanner.step(char)
+		if topLevel && unicode.IsSpace(char) {
+			if fieldStart != -1 {
+				fields = append(fields, expression[fieldStart:index])
+				fieldStart = -1
+			}
+			continue
+		}
+		if fieldStart == -1 {
+			fieldStart = index
+		}
+	}
+	if fieldStart != -1 {
+		fields = append(fields, expression[fieldStart:])
+	}
+	return fields
+}
+
+// isIdentifier reports whether word is a bare or quoted SQL identifier
+func isIdentifier(word string) bool {
+	if len(word) >= 2 {
+		first, last := word[0], word[len(word)-1]
+		if (first == '"' && last == '"') || (first == '`' && last == '`') || (first == '[' && last == ']') {
+			return true
+		}
+	}
+	if word == "" || unicode.IsDigit(rune(word[0])) {
+		return false
+	}
+	for _, char := range word {
+		if !unicode.IsLetter(char) && !unicode.IsDigit(char) && char != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func unquoteIdentifier(identifier string) string {
+	if len(identifier) >= 2 {
+		first, last := identifier[0], identifier[len(identifier)-1]
+		if (first == '"' && last == '"') || (first == '`' && last == '`') || (first == '[' && last == ']') ||
+			(first == '\'' && last == '\'') {
+			return identifier[1 : len(identifier)-1]
+		}
+	}
+	return identifier
+}
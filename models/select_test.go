@@ -0,0 +1,34 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectColumns(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"select pid, name from processes", []string{"pid", "name"}},
+		{"SELECT * FROM processes;", []string{"*"}},
+		{"select p.pid, p.* from processes p", []string{"pid", "*"}},
+		{"select distinct name from processes", []string{"name"}},
+		{"select pid as id, name process from processes", []string{"id", "process"}},
+		{`select "pid", [name], ` + "`path`" + ` from processes`, []string{"pid", "name", "path"}},
+		{"select count(*), max(pid, 1) from processes", []string{"count(*)", "max(pid, 1)"}},
+		{"select count(*) as total from processes", []string{"total"}},
+		{"select 'a, b' as text, 1", []string{"text", "1"}},
+		{"select name from (select name, pid from processes) where pid > 1", []string{"name"}},
+		{"select pid from processes union select pid from listening_ports", []string{"pid"}},
+		{"select pid + 1 from processes", []string{"pid + 1"}},
+		{"select 1", []string{"1"}},
+		{"pragma table_info(processes)", []string{}},
+		{"", []string{}},
+	}
+	for _, test := range tests {
+		if got := SelectColumns(test.query); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SelectColumns(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QueryState is the lifecycle state of a scheduled query as reported by a backend
+type QueryState int
+
+// QueryState constants enum
+const (
+	QueryPending QueryState = iota
+	QueryComplete
+	QueryFailed
+	QueryExpired
+	QueryUnknownHost
+)
+
+// QueryStatus is returned by FetchResults to describe the state of a query. Code and
+// Message carry the osquery error when State is QueryFailed.
+type QueryStatus struct {
+	State   QueryState
+	Code    int
+	Message string
+}
+
+// Convenience statuses for backends
+var (
+	StatusPending     = QueryStatus{State: QueryPending}
+	StatusComplete    = QueryStatus{State: QueryComplete}
+	StatusExpired     = QueryStatus{State: QueryExpired}
+	StatusUnknownHost = QueryStatus{State: QueryUnknownHost}
+)
+
+// StatusFailed builds the status for a query osquery could not run
+func StatusFailed(code int, message string) QueryStatus {
+	return QueryStatus{State: QueryFailed, Code: code, Message: message}
+}
+
+// Pending reports if the query has not posted results yet
+func (status QueryStatus) Pending() bool {
+	return status.State == QueryPending
+}
+
+// Err returns nil for a completed query, otherwise an error describing why the
+// query has no results
+func (status QueryStatus) Err() error {
+	switch status.State {
+	case QueryComplete:
+		return nil
+	case QueryPending:
+		return fmt.Errorf("Query does not have results available yet")
+	case QueryExpired:
+		return fmt.Errorf("Query expired before the host returned results")
+	case QueryUnknownHost:
+		return fmt.Errorf("Query was scheduled for an unknown host")
+	}
+	if status.Message == "" {
+		return fmt.Errorf("Query failed with osquery status code %d", status.Code)
+	}
+	return fmt.Errorf("Query failed with osquery status code %d: %s", status.Code, status.Message)
+}
+
+func (status QueryStatus) String() string {
+	switch status.State {
+	case QueryPending:
+		return "Pending"
+	case QueryComplete:
+		return "Complete"
+	case QueryExpired:
+		return "Expired"
+	case QueryUnknownHost:
+		return "Unknown Host"
+	}
+	if status.Message == "" {
+		return fmt.Sprintf("Status Code %d", status.Code)
+	}
+	return fmt.Sprintf("Status Code %d: %s", status.Code, status.Message)
+}
+
+// ParseQueryStatus converts the free form status strings returned by GoQueryAPI
+// implementations. Strings it does not recognise are treated as complete, which
+// is how goquery has always handled anything other than "Pending".
+func ParseQueryStatus(status string) QueryStatus {
+	normalized := strings.ToLower(strings.TrimSpace(status))
+	switch normalized {
+	case "pending":
+		return StatusPending
+	case "expired":
+		return StatusExpired
+	case "unknown host":
+		return StatusUnknownHost
+	case "failed":
+		return StatusFailed(1, "")
+	}
+
+	var code int
+	if _, err := fmt.Sscanf(normalized, "status code %d", &code); err == nil {
+		message := ""
+		if index := strings.Index(status, ":"); index != -1 {
+			message = strings.TrimSpace(status[index+1:])
+		}
+		if code == 0 {
+			return StatusComplete
+		}
+		return StatusFailed(code, message)
+	}
+	return StatusComplete
+}
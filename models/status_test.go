@@ -0,0 +1,38 @@
+package models
+
+import "testing"
+
+func TestParseQueryStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   QueryStatus
+	}{
+		{"Pending", StatusPending},
+		{" pending\n", StatusPending},
+		{"Complete", StatusComplete},
+		{"", StatusComplete},
+		{"Expired", StatusExpired},
+		{"Unknown Host", StatusUnknownHost},
+		{"Failed", StatusFailed(1, "")},
+		{"Status Code 0", StatusComplete},
+		{"Status Code 1", StatusFailed(1, "")},
+		{"status code 1: no such table: proccesses", StatusFailed(1, "no such table: proccesses")},
+		{"Status Code 2: Error: near \"x\": syntax error", StatusFailed(2, `Error: near "x": syntax error`)},
+	}
+	for _, test := range tests {
+		if got := ParseQueryStatus(test.status); got != test.want {
+			t.Errorf("ParseQueryStatus(%q) = %#v, want %#v", test.status, got, test.want)
+		}
+	}
+}
+
+func TestQueryStatusStringRoundTrip(t *testing.T) {
+	for _, status := range []QueryStatus{
+		StatusPending, StatusComplete, StatusExpired, StatusUnknownHost,
+		StatusFailed(1, ""), StatusFailed(1, "no such table: x"),
+	} {
+		if got := ParseQueryStatus(status.String()); got != status {
+			t.Errorf("ParseQueryStatus(%q) = %#v, want %#v", status.String(), got, status)
+		}
+	}
+}
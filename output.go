@@ -0,0 +1,132 @@
+package goquery
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/AbGuthrie/goquery/v2/commands"
+	"github.com/AbGuthrie/goquery/v2/pipeline"
+	"github.com/AbGuthrie/goquery/v2/session"
+	"github.com/AbGuthrie/goquery/v2/utils"
+)
+
+// whereComparison matches a where stage up to the end of its comparison, whose >
+// filters rows rather than redirecting them
+var whereComparison = regexp.MustCompile(`^where\s+\S+?\s*(!=|!~|<=|>=|=|~|<|>)`)
+
+// splitRedirect separates a trailing "> FILE" or ">> FILE" from input, returning the
+// command and where its output should go. So that comparisons ending a query, like
+// "where pid > 10", still reach the backend, FILE must look like a file name: it
+// has to contain a "." or path separator and not be a number. A redirect ends the
+// line, so with pipeline stages it can only follow the last one, and only after
+// the comparison when that stage is a where.
+func splitRedirect(input string) (command, filePath string, appendOutput bool) {
+	start := 0
+	if _, stages := pipeline.Split(input); len(stages) > 0 {
+		last := stages[len(stages)-1]
+		start = strings.LastIndex(input, last)
+		if match := whereComparison.FindStringIndex(last); match != nil {
+			start += match[1]
+		}
+	}
+
+	redirect := -1
+	var quote rune
+	for index, char := range input[start:] {
+		index += start
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == '>':
+			redirect = index
+		}
+	}
+	if redirect == -1 {
+		return input, "", false
+	}
+
+	operator := redirect
+	if operator > 0 && input[operator-1] == '>' {
+		operator--
+		appendOutput = true
+	}
+	target := strings.TrimSpace(input[redirect+1:])
+	command = strings.TrimSpace(input[:operator])
+	if operator == 0 || !unicode.IsSpace(rune(input[operator-1])) || command == "" || !looksLikeFile(target) {
+		return input, "", false
+	}
+	return command, target, appendOutput
+}
+
+func looksLikeFile(target string) bool {
+	if target == "" || strings.ContainsAny(target, " \t()'\"`;,=<>") {
+		return false
+	}
+	if _, err := strconv.ParseFloat(target, 64); err == nil {
+		return false
+	}
+	return strings.ContainsAny(target, "./\\") || strings.HasPrefix(target, "~")
+}
+
+// executeRedirected runs command with its output written to filePath instead of Out
+func executeRedirected(s *session.Session, command, filePath string, appendOutput bool) error {
+	file, err := utils.OpenOutputFile(filePath, appendOutput)
+	if err != nil {
+		return fmt.Errorf("Could not open %s: %s", filePath, err)
+	}
+	restore := s.Redirect(file)
+	err = Execute(s, command)
+	restore()
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		return fmt.Errorf("Could not write %s: %s", filePath, closeErr)
+	}
+	if err == nil {
+		fmt.Fprintf(s.Err, "Output written to %s\n", filePath)
+	}
+	return err
+}
+
+// executePiped runs command with the results it prints passed through the
+// pipeline stages
+func executePiped(s *session.Session, command string, stages []string) error {
+	stagesPipeline, err := pipeline.Parse(stages)
+	if err != nil {
+		return err
+	}
+	finish := s.Pipe(stagesPipeline.Apply)
+	err = Execute(s, command)
+	if pipeErr := finish(); err == nil && pipeErr != nil {
+		return pipeErr
+	}
+	return err
+}
+
+// executeLet runs command and keeps the results it would have printed in the
+// variable name
+func executeLet(s *session.Session, name, command string) error {
+	if err := commands.ValidateVariableName(name); err != nil {
+		return err
+	}
+	if command == "" {
+		return fmt.Errorf("Usage: .let NAME = COMMAND")
+	}
+	finish := s.Capture()
+	err := Execute(s, command)
+	results, ok := finish()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s printed no results to keep in %s", strings.Fields(command)[0], name)
+	}
+	s.SetVariable(name, results)
+	s.Printf("Set %s to %d row(s) with columns: %s\n", name, results.Len(), strings.Join(results.Columns, ", "))
+	return nil
+}
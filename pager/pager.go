@@ -0,0 +1,365 @@
+// Package pager shows output that is taller than the terminal in a scrollable
+// view, similar to less, so large result sets don't scroll past the prompt.
+package pager
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/printers"
+
+	runewidth "github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// Policies for when results are shown in the pager
+const (
+	// Auto pages output that is taller than the terminal
+	Auto = "auto"
+	// Always pages all output written to a terminal
+	Always = "always"
+	// Never prints output directly
+	Never = "never"
+)
+
+// Terminal escape sequences used to draw the pager
+const (
+	enterAlternateScreen = "\x1b[?1049h"
+	leaveAlternateScreen = "\x1b[?1049l"
+	hideCursor           = "\x1b[?25l"
+	showCursor           = "\x1b[?25h"
+	cursorHome           = "\x1b[H"
+	clearLine            = "\x1b[K"
+	reverseVideo         = "\x1b[7m"
+	resetVideo           = "\x1b[0m"
+)
+
+const help = "q quit, ↑↓ space b scroll, ←→ scroll sideways, / ? search, n N next match"
+
+// Fprint prints results like printers.FprintWithOptions, but shows them in the pager
+// when policy allows it, w and standard input are both terminals and, for Auto, the
+// output doesn't fit on screen. Output that isn't going to a terminal is never paged.
+func Fprint(w io.Writer, mode string, results models.Results, options printers.Options, policy string) error {
+	out, ok := w.(*os.File)
+	if policy == Never || !ok || !term.IsTerminal(int(out.Fd())) || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return printers.FprintWithOptions(w, mode, results, options)
+	}
+	width, height, err := term.GetSize(int(out.Fd()))
+	if err != nil {
+		return printers.FprintWithOptions(w, mode, results, options)
+	}
+
+	fitted := options
+	if fitted.Width == 0 {
+		fitted.Width = width
+	}
+	output := bytes.Buffer{}
+	if err := printers.FprintWithOptions(&output, mode, results, fitted); err != nil {
+		return err
+	}
+	// Leave a line for the prompt that follows the output
+	if policy != Always && bytes.Count(output.Bytes(), []byte("\n")) < height {
+		_, err := out.Write(output.Bytes())
+		return err
+	}
+
+	// The pager scrolls sideways, so wide tables are shown in full rather than
+	// squeezed into the terminal
+	if options.Width == 0 {
+		fitted.Width = -1
+		output.Reset()
+		if err := printers.FprintWithOptions(&output, mode, results, fitted); err != nil {
+			return err
+		}
+	}
+	return Page(os.Stdin, out, output.String())
+}
+
+// Page shows text on the terminal out, reading keys from the terminal in, until
+// the user quits. The terminal is put in raw mode and restored afterwards.
+func Page(in, out *os.File, text string) error {
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return fmt.Errorf("Could not start pager: %s", err)
+	}
+	defer term.Restore(int(in.Fd()), state)
+
+	fmt.Fprint(out, enterAlternateScreen+hideCursor)
+	defer fmt.Fprint(out, showCursor+leaveAlternateScreen)
+
+	view := newView(text)
+	keys := make([]byte, 16)
+	for {
+		view.resize(out)
+		view.draw(out)
+		count, err := in.Read(keys)
+		if err != nil {
+			return err
+		}
+		if !view.handleKey(string(keys[:count]), in, out) {
+			return nil
+		}
+	}
+}
+
+// view is the state of the pager: the lines being shown and which part of them
+// is on screen
+type view struct {
+	lines     []string
+	lineWidth int
+
+	top, left     int
+	width, height int
+
+	pattern        *regexp.Regexp
+	searchForwards bool
+	message        string
+}
+
+func newView(text string) *view {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	view := &view{lines: make([]string, len(lines)), searchForwards: true}
+	for i, line := range lines {
+		view.lines[i] = printableLine(line)
+		if width := runewidth.StringWidth(view.lines[i]); width > view.lineWidth {
+			view.lineWidth = width
+		}
+	}
+	return view
+}
+
+// printableLine expands tabs and replaces control characters, such as escape
+// sequences in query results, that would corrupt the screen
+func printableLine(line string) string {
+	line = strings.Replace(line, "\t", "    ", -1)
+	return strings.Map(func(char rune) rune {
+		if unicode.IsControl(char) {
+			return '?'
+		}
+		return char
+	}, line)
+}
+
+// resize updates the view to the current size of the terminal, so the pager
+// redraws correctly after the window changes
+func (view *view) resize(out *os.File) {
+	width, height, err := term.GetSize(int(out.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	view.width = width
+	// The last line is used for the status bar
+	view.height = height - 1
+	if view.height < 1 {
+		view.height = 1
+	}
+	view.scroll(0, 0)
+}
+
+// scroll moves the view down by lines and right by columns, keeping it within the text
+func (view *view) scroll(lines, columns int) {
+	view.top = clamp(view.top+lines, 0, len(view.lines)-view.height)
+	view.left = clamp(view.left+columns, 0, view.lineWidth-view.width)
+}
+
+func clamp(value, minimum, maximum int) int {
+	if value > maximum {
+		value = maximum
+	}
+	if value < minimum {
+		value = minimum
+	}
+	return value
+}
+
+func (view *view) draw(out *os.File) {
+	screen := bufio.NewWriter(out)
+	screen.WriteString(cursorHome)
+	for row := 0; row < view.height; row++ {
+		if view.top+row < len(view.lines) {
+			screen.WriteString(view.render(view.lines[view.top+row]))
+		} else {
+			screen.WriteString("~")
+		}
+		screen.WriteString(clearLine + "\r\n")
+	}
+	screen.WriteString(reverseVideo + runewidth.Truncate(view.status(), view.width, "…") + resetVideo + clearLine)
+	screen.Flush()
+}
+
+func (view *view) status() string {
+	if view.message != "" {
+		message := view.message
+		view.message = ""
+		return message
+	}
+	bottom := view.top + view.height
+	if bottom > len(view.lines) {
+		bottom = len(view.lines)
+	}
+	status := fmt.Sprintf("lines %d-%d of %d (%d%%)", view.top+1, bottom, len(view.lines), bottom*100/len(view.lines))
+	if view.left > 0 {
+		status += fmt.Sprintf(", column %d", view.left+1)
+	}
+	return status + "  " + help
+}
+
+// render returns the part of line that is on screen, with search matches highlighted
+func (view *view) render(line string) string {
+	var matches [][]int
+	if view.pattern != nil {
+		matches = view.pattern.FindAllStringIndex(line, -1)
+	}
+
+	rendered := strings.Builder{}
+	column := 0
+	highlighted := false
+	for offset, char := range line {
+		charWidth := runewidth.RuneWidth(char)
+		if column+charWidth > view.left+view.width {
+			break
+		}
+		if column >= view.left {
+			if matched := inMatches(matches, offset); matched != highlighted {
+				highlighted = matched
+				if matched {
+					rendered.WriteString(reverseVideo)
+				} else {
+					rendered.WriteString(resetVideo)
+				}
+			}
+			rendered.WriteRune(char)
+		} else if column+charWidth > view.left {
+			// A wide character cut in half by the left edge of the screen
+			rendered.WriteString(strings.Repeat(" ", column+charWidth-view.left))
+		}
+		column += charWidth
+	}
+	if highlighted {
+		rendered.WriteString(resetVideo)
+	}
+	return rendered.String()
+}
+
+func inMatches(matches [][]int, offset int) bool {
+	for _, match := range matches {
+		if offset >= match[0] && offset < match[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// handleKey acts on a key press, returning false when the pager should close
+func (view *view) handleKey(key string, in, out *os.File) bool {
+	page := view.height
+	switch key {
+	case "q", "Q", "\x1b", "\x03":
+		return false
+	case "j", "\r", "\n", "\x0e", "\x1b[B", "\x1bOB":
+		view.scroll(1, 0)
+	case "k", "\x10", "\x1b[A", "\x1bOA":
+		view.scroll(-1, 0)
+	case " ", "f", "\x06", "\x1b[6~":
+		view.scroll(page, 0)
+	case "b", "\x02", "\x1b[5~":
+		view.scroll(-page, 0)
+	case "d", "\x04":
+		view.scroll(page/2, 0)
+	case "u", "\x15":
+		view.scroll(-page/2, 0)
+	case "g", "<", "\x1b[H", "\x1bOH", "\x1b[1~":
+		view.scroll(-len(view.lines), 0)
+	case "G", ">", "\x1b[F", "\x1bOF", "\x1b[4~":
+		view.scroll(len(view.lines), 0)
+	case "l", "\x1b[C", "\x1bOC":
+		view.scroll(0, view.width/2)
+	case "h", "\x1b[D", "\x1bOD":
+		view.scroll(0, -view.width/2)
+	case "/", "?":
+		text, ok := view.readPattern(in, out, key)
+		if !ok {
+			break
+		}
+		if text != "" {
+			view.pattern = regexp.MustCompile("(?i)" + regexp.QuoteMeta(text))
+		}
+		view.searchForwards = key == "/"
+		view.search(view.searchForwards, 0)
+	case "n":
+		view.search(view.searchForwards, 1)
+	case "N":
+		view.search(!view.searchForwards, 1)
+	}
+	return true
+}
+
+// readPattern reads a search pattern on the status line. An empty pattern repeats
+// the previous search, and false is returned if the search was abandoned.
+func (view *view) readPattern(in, out *os.File, prompt string) (string, bool) {
+	pattern := []rune{}
+	keys := make([]byte, 16)
+	fmt.Fprint(out, showCursor)
+	defer fmt.Fprint(out, hideCursor)
+	for {
+		fmt.Fprintf(out, "\x1b[%d;1H%s%s%s", view.height+1, prompt, string(pattern), clearLine)
+		count, err := in.Read(keys)
+		if err != nil {
+			return "", false
+		}
+		key := string(keys[:count])
+		switch {
+		case key == "\r" || key == "\n":
+			return string(pattern), true
+		case key == "\x1b" || key == "\x03":
+			return "", false
+		case key == "\x7f" || key == "\x08":
+			if len(pattern) == 0 {
+				return "", false
+			}
+			pattern = pattern[:len(pattern)-1]
+		case strings.HasPrefix(key, "\x1b"):
+			// Ignore arrow keys and other escape sequences
+		default:
+			for _, char := range key {
+				if !unicode.IsControl(char) {
+					pattern = append(pattern, char)
+				}
+			}
+		}
+	}
+}
+
+// search moves the view to the next line matching the current pattern, starting
+// skip lines past the top of the screen, and scrolls sideways to show the match
+func (view *view) search(forwards bool, skip int) {
+	if view.pattern == nil {
+		view.message = "No previous search"
+		return
+	}
+	step := 1
+	if !forwards {
+		step = -1
+	}
+	for index := view.top + step*skip; index >= 0 && index < len(view.lines); index += step {
+		match := view.pattern.FindStringIndex(view.lines[index])
+		if match == nil {
+			continue
+		}
+		view.top = index
+		column := runewidth.StringWidth(view.lines[index][:match[0]])
+		if column < view.left || column >= view.left+view.width {
+			view.left = column - view.width/4
+		}
+		view.scroll(0, 0)
+		return
+	}
+	view.message = "Pattern not found"
+}
@@ -0,0 +1,164 @@
+package pager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/printers"
+)
+
+func TestPrintableLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"plain", "plain"},
+		{"a\tb", "a    b"},
+		{"\x1b[31mred\x1b[0m", "?[31mred?[0m"},
+		{"bell\x07", "bell?"},
+		{"carriage\rreturn", "carriage?return"},
+		{"\u009bcsi", "?csi"},
+		{"日本語", "日本語"},
+	}
+	for _, test := range tests {
+		if got := printableLine(test.line); got != test.want {
+			t.Errorf("printableLine(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestNewView(t *testing.T) {
+	view := newView("one\n\x1b[2Jtwo\n日本語\n")
+	want := []string{"one", "?[2Jtwo", "日本語"}
+	if !reflect.DeepEqual(view.lines, want) {
+		t.Errorf("lines = %q, want %q", view.lines, want)
+	}
+	if view.lineWidth != 7 {
+		t.Errorf("lineWidth = %d, want 7", view.lineWidth)
+	}
+}
+
+func TestScroll(t *testing.T) {
+	view := newView("0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n0123456789")
+	view.width, view.height = 4, 3
+
+	tests := []struct {
+		lines, columns int
+		top, left      int
+	}{
+		{1, 0, 1, 0},
+		{100, 0, 8, 0},
+		{-3, 0, 5, 0},
+		{-100, 0, 0, 0},
+		{0, 2, 0, 2},
+		{0, 100, 0, 6},
+		{0, -100, 0, 0},
+	}
+	for _, test := range tests {
+		view.scroll(test.lines, test.columns)
+		if view.top != test.top || view.left != test.left {
+			t.Errorf("scroll(%d, %d) moved to %d, %d, want %d, %d", test.lines, test.columns, view.top,
+				view.left, test.top, test.left)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		line    string
+		left    int
+		pattern string
+		want    string
+	}{
+		{"abcdefgh", 0, "", "abcd"},
+		{"abcdefgh", 2, "", "cdef"},
+		{"日本語", 0, "", "日本"},
+		{"日本語", 1, "", " 本"},
+		{"abcdefgh", 0, "bc", "a" + reverseVideo + "bc" + resetVideo + "d"},
+		{"abcdefgh", 0, "cde", "ab" + reverseVideo + "cd" + resetVideo},
+	}
+	for _, test := range tests {
+		view := &view{left: test.left, width: 4}
+		if test.pattern != "" {
+			view.pattern = regexp.MustCompile(regexp.QuoteMeta(test.pattern))
+		}
+		if got := view.render(test.line); got != test.want {
+			t.Errorf("render(%q) at column %d = %q, want %q", test.line, test.left, got, test.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	view := newView("alpha\nbeta\ngamma\nbeta again\ndelta")
+	view.width, view.height = 80, 2
+
+	view.search(true, 0)
+	if view.message != "No previous search" {
+		t.Errorf("message = %q, want No previous search", view.message)
+	}
+
+	view.pattern = regexp.MustCompile("beta")
+	view.search(true, 0)
+	if view.top != 1 {
+		t.Errorf("first match at line %d, want 1", view.top)
+	}
+	view.search(true, 1)
+	if view.top != 3 {
+		t.Errorf("next match at line %d, want 3", view.top)
+	}
+	view.search(false, 1)
+	if view.top != 1 {
+		t.Errorf("previous match at line %d, want 1", view.top)
+	}
+
+	view.message = ""
+	view.pattern = regexp.MustCompile("missing")
+	view.search(true, 0)
+	if view.message != "Pattern not found" || view.top != 1 {
+		t.Errorf("search for a missing pattern moved to %d with %q", view.top, view.message)
+	}
+}
+
+func TestFprintWithoutTerminal(t *testing.T) {
+	results := models.Results{
+		Columns: []string{"name"},
+		Rows:    models.Rows{{"name": "a\x1b[2Jb"}},
+	}
+	want := bytes.Buffer{}
+	if err := printers.FprintWithOptions(&want, "line", results, printers.Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, policy := range []string{Auto, Always, Never} {
+		output := bytes.Buffer{}
+		if err := Fprint(&output, "line", results, printers.Options{}, policy); err != nil {
+			t.Fatalf("Fprint with %s: %s", policy, err)
+		}
+		if output.String() != want.String() {
+			t.Errorf("Fprint with %s = %q, want %q", policy, output.String(), want.String())
+		}
+
+		// A file that isn't a terminal is written to directly too
+		file, err := os.Create(filepath.Join(t.TempDir(), "output"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Fprint(file, "line", results, printers.Options{}, policy)
+		file.Close()
+		if err != nil {
+			t.Fatalf("Fprint to a file with %s: %s", policy, err)
+		}
+		written, err := os.ReadFile(file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(written) != want.String() {
+			t.Errorf("Fprint to a file with %s = %q, want %q", policy, written, want.String())
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+// operator parses the arguments of a pipeline stage into the stage itself
+type operator struct {
+	usage       string
+	description string
+	parse       func(args string) (Stage, error)
+}
+
+var operators = map[string]operator{
+	"where": operator{
+		usage:       "where COLUMN (= != < <= > >= ~ !~) VALUE",
+		description: "Keep rows where a column compares to a value, ~ matches a regular expression",
+		parse:       parseWhere,
+	},
+	"sort": operator{
+		usage:       "sort [-]COLUMN[,[-]COLUMN...]",
+		description: "Sort rows by columns, descending when prefixed with -",
+		parse:       parseSort,
+	},
+	"cols": operator{
+		usage:       "cols COLUMN[,COLUMN...]",
+		description: "Keep only the listed columns, in the order given",
+		parse:       parseCols,
+	},
+	"head": operator{
+		usage:       "head [COUNT]",
+		description: "Keep the first rows, 10 by default",
+		parse: func(args string) (Stage, error) {
+			return parseLimit(args, false)
+		},
+	},
+	"tail": operator{
+		usage:       "tail [COUNT]",
+		description: "Keep the last rows, 10 by default",
+		parse: func(args string) (Stage, error) {
+			return parseLimit(args, true)
+		},
+	},
+	"count": operator{
+		usage:       "count",
+		description: "Replace the rows with how many there are",
+		parse:       parseCount,
+	},
+}
+
+// Names returns the sorted names of the pipeline operators
+func Names() []string {
+	names := make([]string, 0, len(operators))
+	for name := range operators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Description returns what the named operator does and how to use it
+func Description(name string) string {
+	operator, ok := operators[name]
+	if !ok {
+		return ""
+	}
+	return operator.description + ": " + operator.usage
+}
+
+var whereExpression = regexp.MustCompile(`^(\S+?)\s*(!=|!~|<=|>=|=|~|<|>)\s*(.*)$`)
+
+func parseWhere(args string) (Stage, error) {
+	match := whereExpression.FindStringSubmatch(args)
+	if match == nil {
+		return nil, fmt.Errorf("Expected a column, comparison and value")
+	}
+	name, comparison, value := match[1], match[2], unquote(strings.TrimSpace(match[3]))
+
+	var keep func(cell string) bool
+	switch comparison {
+	case "~", "!~":
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid regular expression: %s", err)
+		}
+		keep = func(cell string) bool {
+			return pattern.MatchString(cell) == (comparison == "~")
+		}
+	default:
+		keep = func(cell string) bool {
+			order := compareValues(cell, value)
+			switch comparison {
+			case "=":
+				return order == 0
+			case "!=":
+				return order != 0
+			case "<":
+				return order < 0
+			case "<=":
+				return order <= 0
+			case ">":
+				return order > 0
+			}
+			return order >= 0
+		}
+	}
+
+	return func(results models.Results) (models.Results, error) {
+		if results.Len() == 0 {
+			return results, nil
+		}
+		column, err := findColumn(results, name)
+		if err != nil {
+			return results, err
+		}
+		rows := make(models.Rows, 0)
+		for _, row := range results.Rows {
+			if keep(row[column]) {
+				rows = append(rows, row)
+			}
+		}
+		return models.Results{Columns: results.Columns, Rows: rows}, nil
+	}, nil
+}
+
+func parseSort(args string) (Stage, error) {
+	keys := splitList(args)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("Expected at least one column")
+	}
+
+	return func(results models.Results) (models.Results, error) {
+		if results.Len() == 0 {
+			return results, nil
+		}
+		columns := make([]string, len(keys))
+		descending := make([]bool, len(keys))
+		for i, key := range keys {
+			descending[i] = strings.HasPrefix(key, "-")
+			column, err := findColumn(results, strings.TrimPrefix(key, "-"))
+			if err != nil {
+				return results, err
+			}
+			columns[i] = column
+		}
+
+		rows := append(models.Rows{}, results.Rows...)
+		sort.SliceStable(rows, func(i, j int) bool {
+			for k, column := range columns {
+				order := compareValues(rows[i][column], rows[j][column])
+				if order == 0 {
+					continue
+				}
+				return (order < 0) != descending[k]
+			}
+			return false
+		})
+		return models.Results{Columns: results.Columns, Rows: rows}, nil
+	}, nil
+}
+
+func parseCols(args string) (Stage, error) {
+	names := splitList(args)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("Expected at least one column")
+	}
+
+	return func(results models.Results) (models.Results, error) {
+		if results.Len() == 0 {
+			return models.Results{Columns: names, Rows: results.Rows}, nil
+		}
+		columns := make([]string, len(names))
+		for i, name := range names {
+			column, err := findColumn(results, name)
+			if err != nil {
+				return results, err
+			}
+			columns[i] = column
+		}
+		rows := make(models.Rows, 0, results.Len())
+		for _, row := range results.Rows {
+			kept := map[string]string{}
+			for _, column := range columns {
+				kept[column] = row[column]
+			}
+			rows = append(rows, kept)
+		}
+		return models.Results{Columns: columns, Rows: rows}, nil
+	}, nil
+}
+
+func parseLimit(args string, last bool) (Stage, error) {
+	count := 10
+	if args != "" {
+		var err error
+		if count, err = strconv.Atoi(args); err != nil || count < 0 {
+			return nil, fmt.Errorf("Expected a number of rows")
+		}
+	}
+
+	return func(results models.Results) (models.Results, error) {
+		if results.Len() <= count {
+			return results, nil
+		}
+		rows := results.Rows[:count]
+		if last {
+			rows = results.Rows[results.Len()-count:]
+		}
+		return models.Results{Columns: results.Columns, Rows: rows}, nil
+	}, nil
+}
+
+func parseCount(args string) (Stage, error) {
+	if args != "" {
+		return nil, fmt.Errorf("Takes no arguments")
+	}
+	return func(results models.Results) (models.Results, error) {
+		return models.Results{
+			Columns: []string{"count"},
+			Rows:    models.Rows{{"count": strconv.Itoa(results.Len())}},
+		}, nil
+	}, nil
+}
+
+// compareValues orders two cells, numerically when both are numbers as osquery
+// returns every value as a string
+func compareValues(a, b string) int {
+	aNumber, aErr := strconv.ParseFloat(a, 64)
+	bNumber, bErr := strconv.ParseFloat(b, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case aNumber < bNumber:
+			return -1
+		case aNumber > bNumber:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+var testResults = models.Results{
+	Columns: []string{"pid", "Name", "path"},
+	Rows: models.Rows{
+		{"pid": "1", "Name": "init", "path": "/sbin/init"},
+		{"pid": "42", "Name": "sshd", "path": "/usr/sbin/sshd"},
+		{"pid": "100", "Name": "bash", "path": "/bin/bash"},
+		{"pid": "9", "Name": "sshd", "path": "/usr/sbin/sshd"},
+	},
+}
+
+// column returns the values of one column of results, in row order
+func column(results models.Results, name string) []string {
+	values := make([]string, 0, results.Len())
+	for _, row := range results.Rows {
+		values = append(values, row[name])
+	}
+	return values
+}
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		stages  []string
+		columns []string
+		column  string
+		want    []string
+	}{
+		{[]string{"where pid > 9"}, []string{"pid", "Name", "path"}, "pid", []string{"42", "100"}},
+		{[]string{"where pid>=9"}, []string{"pid", "Name", "path"}, "pid", []string{"42", "100", "9"}},
+		{[]string{"where pid < 10"}, []string{"pid", "Name", "path"}, "pid", []string{"1", "9"}},
+		{[]string{"where pid <= 1"}, []string{"pid", "Name", "path"}, "pid", []string{"1"}},
+		{[]string{"where name = sshd"}, []string{"pid", "Name", "path"}, "pid", []string{"42", "9"}},
+		{[]string{"where name = 'sshd'"}, []string{"pid", "Name", "path"}, "pid", []string{"42", "9"}},
+		{[]string{"where name != sshd"}, []string{"pid", "Name", "path"}, "pid", []string{"1", "100"}},
+		{[]string{"where path ~ ^/usr/"}, []string{"pid", "Name", "path"}, "pid", []string{"42", "9"}},
+		{[]string{"where path !~ sbin"}, []string{"pid", "Name", "path"}, "pid", []string{"100"}},
+		{[]string{"where pid = 01"}, []string{"pid", "Name", "path"}, "pid", []string{"1"}},
+		{[]string{"sort pid"}, []string{"pid", "Name", "path"}, "pid", []string{"1", "9", "42", "100"}},
+		{[]string{"sort -pid"}, []string{"pid", "Name", "path"}, "pid", []string{"100", "42", "9", "1"}},
+		{[]string{"sort name,-pid"}, []string{"pid", "Name", "path"}, "pid", []string{"100", "1", "42", "9"}},
+		{[]string{"cols path, PID"}, []string{"path", "pid"}, "pid", []string{"1", "42", "100", "9"}},
+		{[]string{"head 2"}, []string{"pid", "Name", "path"}, "pid", []string{"1", "42"}},
+		{[]string{"head"}, []string{"pid", "Name", "path"}, "pid", []string{"1", "42", "100", "9"}},
+		{[]string{"tail 1"}, []string{"pid", "Name", "path"}, "pid", []string{"9"}},
+		{[]string{"head 0"}, []string{"pid", "Name", "path"}, "pid", []string{}},
+		{[]string{"count"}, []string{"count"}, "count", []string{"4"}},
+		{[]string{"where name = sshd", "count"}, []string{"count"}, "count", []string{"2"}},
+		{[]string{"sort -pid", "tail 2", "cols name"}, []string{"Name"}, "Name", []string{"sshd", "init"}},
+	}
+	for _, test := range tests {
+		pipeline, err := Parse(test.stages)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %s", test.stages, err)
+			continue
+		}
+		got, err := pipeline.Apply(testResults)
+		if err != nil {
+			t.Errorf("Apply(%q) failed: %s", test.stages, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.Columns, test.columns) {
+			t.Errorf("Apply(%q) columns = %q, want %q", test.stages, got.Columns, test.columns)
+		}
+		if values := column(got, test.column); !reflect.DeepEqual(values, test.want) {
+			t.Errorf("Apply(%q) %s = %q, want %q", test.stages, test.column, values, test.want)
+		}
+	}
+}
+
+func TestOperatorsUnknownColumn(t *testing.T) {
+	for _, stage := range []string{"where user = root", "sort user", "cols pid,user"} {
+		pipeline, err := Parse([]string{stage})
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %s", stage, err)
+			continue
+		}
+		if _, err := pipeline.Apply(testResults); err == nil {
+			t.Errorf("Apply(%q) succeeded, want an error", stage)
+		}
+	}
+}
+
+func TestOperatorsNoRows(t *testing.T) {
+	empty := models.Results{Columns: []string{}, Rows: models.Rows{}}
+	pipeline, err := Parse([]string{"where user = root", "sort -user", "cols user"})
+	if err != nil {
+		t.Fatalf("Parse failed: %s", err)
+	}
+	got, err := pipeline.Apply(empty)
+	if err != nil {
+		t.Fatalf("Apply to no rows failed: %s", err)
+	}
+	if got.Len() != 0 || !reflect.DeepEqual(got.Columns, []string{"user"}) {
+		t.Errorf("Apply to no rows = %v, want no rows with the user column", got)
+	}
+}
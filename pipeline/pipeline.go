@@ -0,0 +1,116 @@
+// Package pipeline filters, sorts and trims query results on the client, so they
+// can be narrowed down without another round trip to the host. A pipeline is
+// written after a command as stages separated by "|", for example
+// ".query select * from processes | where name ~ ssh | sort -pid | head 20".
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+// Stage is a single step of a pipeline, turning results into new results
+type Stage func(results models.Results) (models.Results, error)
+
+// Pipeline is a series of stages applied in order
+type Pipeline []Stage
+
+// Apply runs results through every stage of the pipeline
+func (pipeline Pipeline) Apply(results models.Results) (models.Results, error) {
+	for _, stage := range pipeline {
+		var err error
+		if results, err = stage(results); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// Split separates the pipeline stages from the command at the start of input. Stages
+// are separated by a single "|" outside quotes and parentheses, so SQL's "||"
+// operator and bitwise ors in parentheses are left as part of the command.
+func Split(input string) (command string, stages []string) {
+	parts := make([]string, 0)
+	depth := 0
+	var quote rune
+	partStart := 0
+	for index, char := range input {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case char == '|' && depth == 0:
+			previousPipe := index > 0 && input[index-1] == '|'
+			nextPipe := index < len(input)-1 && input[index+1] == '|'
+			if !previousPipe && !nextPipe {
+				parts = append(parts, input[partStart:index])
+				partStart = index + 1
+			}
+		}
+	}
+	parts = append(parts, input[partStart:])
+
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts[0], parts[1:]
+}
+
+// Parse builds a pipeline from stages as returned by Split
+func Parse(stages []string) (Pipeline, error) {
+	pipeline := make(Pipeline, 0, len(stages))
+	for _, text := range stages {
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("Empty pipeline stage, available operators: %v", Names())
+		}
+		operator, ok := operators[fields[0]]
+		if !ok {
+			return nil, fmt.Errorf("%s is not a pipeline operator, available operators: %v", fields[0], Names())
+		}
+		stage, err := operator.parse(strings.TrimSpace(text[len(fields[0]):]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s, usage: %s", fields[0], err, operator.usage)
+		}
+		pipeline = append(pipeline, stage)
+	}
+	return pipeline, nil
+}
+
+// findColumn returns the column of results matching name case insensitively
+func findColumn(results models.Results, name string) (string, error) {
+	for _, column := range results.Columns {
+		if strings.EqualFold(column, name) {
+			return column, nil
+		}
+	}
+	return "", fmt.Errorf("No column named %s, columns are: %s", name, strings.Join(results.Columns, ", "))
+}
+
+// splitList splits a list of column names separated by commas and/or spaces
+func splitList(text string) []string {
+	return strings.FieldsFunc(text, func(char rune) bool {
+		return char == ',' || unicode.IsSpace(char)
+	})
+}
+
+// unquote removes matching single or double quotes around value
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if first == last && (first == '\'' || first == '"') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
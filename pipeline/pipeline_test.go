@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		input   string
+		command string
+		stages  []string
+	}{
+		{".query select 1", ".query select 1", []string{}},
+		{".query select * from processes | where name ~ ssh | head 5", ".query select * from processes",
+			[]string{"where name ~ ssh", "head 5"}},
+		{".query select 'a' || 'b' as c | count", ".query select 'a' || 'b' as c", []string{"count"}},
+		{".query select (1 | 2) as bits|count", ".query select (1 | 2) as bits", []string{"count"}},
+		{".query select 'a|b' as c | where c = \"x|y\"", ".query select 'a|b' as c", []string{`where c = "x|y"`}},
+		{".query select 1 | ", ".query select 1", []string{""}},
+	}
+	for _, test := range tests {
+		command, stages := Split(test.input)
+		if command != test.command || !reflect.DeepEqual(stages, test.stages) {
+			t.Errorf("Split(%q) = %q, %q, want %q, %q", test.input, command, stages, test.command, test.stages)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := [][]string{
+		{""},
+		{"grep ssh"},
+		{"where name"},
+		{"where name ~ ("},
+		{"sort"},
+		{"cols ,"},
+		{"head -1"},
+		{"tail ten"},
+		{"count 5"},
+		{"head 5", "uniq"},
+	}
+	for _, stages := range tests {
+		if _, err := Parse(stages); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", stages)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package printers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+
+	runewidth "github.com/mattn/go-runewidth"
+)
+
+func init() {
+	Register("json", PrinterFunc(printJSON))
+	Register("line", PrinterFunc(printLines))
+}
+
+func printJSON(w io.Writer, results models.Results) error {
+	if results.Len() == 0 {
+		_, err := fmt.Fprintf(w, "[]\n")
+		return err
+	}
+	columns := resultColumns(results)
+	out := bufio.NewWriter(w)
+	out.WriteString("[\n")
+	for i, row := range results.Rows {
+		out.WriteString("    ")
+		if err := writeJSONObject(out, columns, row, "    "); err != nil {
+			return err
+		}
+		if i < len(results.Rows)-1 {
+			out.WriteString(",")
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString("]\n")
+	return out.Flush()
+}
+
+// writeJSONObject writes row as a JSON object with its keys in column order, leaving
+// out columns the row doesn't have. With an indent each key is put on its own line.
+func writeJSONObject(out *bufio.Writer, columns []string, row map[string]string, indent string) error {
+	out.WriteString("{")
+	written := 0
+	for _, column := range columns {
+		value, ok := row[column]
+		if !ok {
+			continue
+		}
+		if written > 0 {
+			out.WriteString(",")
+		}
+		written++
+		separator := ":"
+		if indent != "" {
+			out.WriteString("\n" + indent + indent)
+			separator = ": "
+		}
+		key, err := marshalJSONString(column)
+		if err != nil {
+			return err
+		}
+		encodedValue, err := marshalJSONString(value)
+		if err != nil {
+			return err
+		}
+		out.Write(key)
+		out.WriteString(separator)
+		out.Write(encodedValue)
+	}
+	if indent != "" && written > 0 {
+		out.WriteString("\n" + indent)
+	}
+	out.WriteString("}")
+	return nil
+}
+
+// marshalJSONString encodes value without escaping HTML characters, as results are
+// read by people and tools rather than embedded in web pages
+func marshalJSONString(value string) ([]byte, error) {
+	buffer := bytes.Buffer{}
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, fmt.Errorf("Could not format query results: %s", err)
+	}
+	return bytes.TrimRight(buffer.Bytes(), "\n"), nil
+}
+
+func printLines(w io.Writer, results models.Results) error {
+	if results.Len() == 0 {
+		return nil
+	}
+	out := bufio.NewWriter(w)
+	columns := resultColumns(results)
+	// To center align keys with "=" get longest length key name
+	keyPadding := 0
+	for _, key := range columns {
+		if width := runewidth.StringWidth(key); width > keyPadding {
+			keyPadding = width
+		}
+	}
+	for _, row := range results.Rows {
+		for _, key := range columns {
+			fmt.Fprintf(out, "%s%s = %s\n", strings.Repeat(" ", keyPadding-runewidth.StringWidth(key)), key, row[key])
+		}
+		fmt.Fprintf(out, "\n")
+	}
+	return out.Flush()
+}
+
+// resultColumns returns the columns to print results with. Results built by hand
+// without columns are printed with their columns sorted.
+func resultColumns(results models.Results) []string {
+	if len(results.Columns) > 0 {
+		return results.Columns
+	}
+	return models.OrderColumns(nil, results.Rows)
+}
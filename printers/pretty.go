@@ -0,0 +1,250 @@
+package printers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+
+	runewidth "github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// Overflow policies for tables wider than the terminal
+const (
+	// OverflowTruncate cuts long cells short, marking them with an ellipsis
+	OverflowTruncate = "truncate"
+	// OverflowWrap splits long cells over several lines
+	OverflowWrap = "wrap"
+)
+
+// minimumColumnWidth is the narrowest a column is shrunk to when fitting a table to
+// the terminal. Tables that still don't fit are printed in line mode instead.
+const minimumColumnWidth = 6
+
+// prettyPrinter draws results as a table sized by display width, so wide
+// characters line up, shrinking its widest columns to fit the terminal
+type prettyPrinter struct{}
+
+func init() {
+	Register("pretty", prettyPrinter{})
+}
+
+// Print implements Printer
+func (printer prettyPrinter) Print(w io.Writer, results models.Results) error {
+	return printer.PrintWithOptions(w, results, Options{})
+}
+
+// PrintWithOptions implements OptionsPrinter
+func (printer prettyPrinter) PrintWithOptions(w io.Writer, results models.Results, options Options) error {
+	if results.Len() == 0 {
+		return nil
+	}
+
+	wrap := options.Overflow == OverflowWrap
+	columns := resultColumns(results)
+	rows := make([][]string, 0, results.Len())
+	for _, row := range results.Rows {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = printableCell(row[column], wrap)
+		}
+		rows = append(rows, cells)
+	}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = printableCell(column, false)
+	}
+
+	width := options.Width
+	if width == 0 {
+		width = TerminalWidth(w)
+	}
+	columnWidths, fits := fitColumnWidths(naturalColumnWidths(header, rows), width)
+	if !fits {
+		return printLines(w, results)
+	}
+
+	out := bufio.NewWriter(w)
+	dividerLength := 1
+	for _, columnWidth := range columnWidths {
+		// Each column is printed as "| value " so add the divider and spaces
+		dividerLength += columnWidth + 3
+	}
+	divider := strings.Repeat("-", dividerLength)
+
+	fmt.Fprintf(out, "%s\n", divider)
+	writeTableRow(out, header, columnWidths, false)
+	fmt.Fprintf(out, "%s\n", divider)
+	for _, cells := range rows {
+		writeTableRow(out, cells, columnWidths, wrap)
+	}
+	fmt.Fprintf(out, "%s\n", divider)
+	return out.Flush()
+}
+
+// writeTableRow writes one row of cells, cut to their column widths or, when
+// wrapping, continued over as many lines as the tallest cell needs
+func writeTableRow(out *bufio.Writer, cells []string, columnWidths []int, wrap bool) {
+	cellLines := make([][]string, len(cells))
+	height := 1
+	for i, cell := range cells {
+		if wrap {
+			cellLines[i] = wrapCell(cell, columnWidths[i])
+		} else {
+			cellLines[i] = []string{runewidth.Truncate(cell, columnWidths[i], "…")}
+		}
+		if len(cellLines[i]) > height {
+			height = len(cellLines[i])
+		}
+	}
+
+	for line := 0; line < height; line++ {
+		for i, lines := range cellLines {
+			text := ""
+			if line < len(lines) {
+				text = lines[line]
+			}
+			fmt.Fprintf(out, "| %s%s ", text, strings.Repeat(" ", columnWidths[i]-runewidth.StringWidth(text)))
+		}
+		fmt.Fprintf(out, "|\n")
+	}
+}
+
+// naturalColumnWidths returns the display width each column needs to show every
+// cell in full
+func naturalColumnWidths(header []string, rows [][]string) []int {
+	widths := make([]int, len(header))
+	for i, name := range header {
+		// They key may be longer than the values in some cases like inode
+		widths[i] = runewidth.StringWidth(name)
+	}
+	for _, cells := range rows {
+		for i, cell := range cells {
+			for _, line := range strings.Split(cell, "\n") {
+				if lineWidth := runewidth.StringWidth(line); lineWidth > widths[i] {
+					widths[i] = lineWidth
+				}
+			}
+		}
+	}
+	return widths
+}
+
+// fitColumnWidths shrinks the widest columns until the table is at most maxWidth
+// wide, reporting false if it can't fit even with every column at its minimum.
+// A maxWidth of zero or less means any width fits.
+func fitColumnWidths(natural []int, maxWidth int) ([]int, bool) {
+	// Every column adds "| " and " " around its value, plus the final "|"
+	available := maxWidth - len(natural)*3 - 1
+	total, minimumTotal := 0, 0
+	minimums := make([]int, len(natural))
+	for i, width := range natural {
+		total += width
+		minimums[i] = width
+		if minimums[i] > minimumColumnWidth {
+			minimums[i] = minimumColumnWidth
+		}
+		minimumTotal += minimums[i]
+	}
+	if maxWidth <= 0 || total <= available {
+		return natural, true
+	}
+	if minimumTotal > available {
+		return natural, false
+	}
+
+	// Find the largest cap on column widths that fits, then share out what is
+	// left over between the capped columns
+	capped := func(limit int) ([]int, int) {
+		widths := make([]int, len(natural))
+		sum := 0
+		for i, width := range natural {
+			widths[i] = width
+			if widths[i] > limit {
+				widths[i] = limit
+			}
+			if widths[i] < minimums[i] {
+				widths[i] = minimums[i]
+			}
+			sum += widths[i]
+		}
+		return widths, sum
+	}
+	low, high := 0, 0
+	for _, width := range natural {
+		if width > high {
+			high = width
+		}
+	}
+	for low < high {
+		middle := (low + high + 1) / 2
+		if _, sum := capped(middle); sum <= available {
+			low = middle
+		} else {
+			high = middle - 1
+		}
+	}
+	widths, sum := capped(low)
+	for i := range widths {
+		if sum >= available {
+			break
+		}
+		if widths[i] < natural[i] {
+			widths[i]++
+			sum++
+		}
+	}
+	return widths, true
+}
+
+// printableCell replaces characters that would break the table layout. New lines
+// are kept when wrapping as they are a natural place to break a cell.
+func printableCell(value string, keepNewlines bool) string {
+	value = strings.Replace(value, "\r\n", "\n", -1)
+	value = strings.Replace(value, "\t", " ", -1)
+	value = strings.Replace(value, "\r", " ", -1)
+	if !keepNewlines {
+		value = strings.Replace(value, "\n", " ", -1)
+	}
+	return value
+}
+
+// wrapCell splits value into lines no wider than width, breaking at new lines and
+// otherwise wherever the width runs out
+func wrapCell(value string, width int) []string {
+	lines := make([]string, 0)
+	for _, paragraph := range strings.Split(value, "\n") {
+		line := strings.Builder{}
+		lineWidth := 0
+		for _, char := range paragraph {
+			charWidth := runewidth.RuneWidth(char)
+			if lineWidth+charWidth > width && lineWidth > 0 {
+				lines = append(lines, line.String())
+				line.Reset()
+				lineWidth = 0
+			}
+			line.WriteRune(char)
+			lineWidth += charWidth
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// TerminalWidth returns the width of the terminal w writes to, or zero when w is
+// not a terminal so piped output is never cut short
+func TerminalWidth(w io.Writer) int {
+	file, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(file.Fd())) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(file.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
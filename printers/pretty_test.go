@@ -0,0 +1,141 @@
+package printers
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+func TestFitColumnWidths(t *testing.T) {
+	tests := []struct {
+		natural  []int
+		maxWidth int
+		want     []int
+		fits     bool
+	}{
+		{[]int{3, 4}, 0, []int{3, 4}, true},
+		{[]int{3, 4}, -1, []int{3, 4}, true},
+		{[]int{3, 4}, 14, []int{3, 4}, true},
+		{[]int{10, 20}, 30, []int{10, 13}, true},
+		{[]int{3, 20}, 20, []int{3, 10}, true},
+		{[]int{30, 30}, 40, []int{17, 16}, true},
+		{[]int{2, 40, 3}, 30, []int{2, 15, 3}, true},
+		{[]int{10, 20}, 18, []int{10, 20}, false},
+		{[]int{6, 6, 6}, 27, []int{6, 6, 6}, false},
+	}
+	for _, test := range tests {
+		got, fits := fitColumnWidths(test.natural, test.maxWidth)
+		if !reflect.DeepEqual(got, test.want) || fits != test.fits {
+			t.Errorf("fitColumnWidths(%v, %d) = %v, %t, want %v, %t", test.natural, test.maxWidth, got, fits,
+				test.want, test.fits)
+		}
+	}
+}
+
+func TestWrapCell(t *testing.T) {
+	tests := []struct {
+		value string
+		width int
+		want  []string
+	}{
+		{"", 3, []string{""}},
+		{"abcdef", 3, []string{"abc", "def"}},
+		{"abcdefg", 3, []string{"abc", "def", "g"}},
+		{"ab\ncd", 5, []string{"ab", "cd"}},
+		{"日本語テキスト", 4, []string{"日本", "語テ", "キス", "ト"}},
+		{"日本語", 3, []string{"日", "本", "語"}},
+		{"日本", 1, []string{"日", "本"}},
+	}
+	for _, test := range tests {
+		if got := wrapCell(test.value, test.width); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("wrapCell(%q, %d) = %q, want %q", test.value, test.width, got, test.want)
+		}
+	}
+}
+
+func TestPrettyPrinter(t *testing.T) {
+	wide := models.Results{
+		Columns: []string{"name", "path"},
+		Rows:    models.Rows{{"name": "abcdefghijkl", "path": "/x"}, {"name": "日本語テキスト", "path": "/tmp"}},
+	}
+	tests := []struct {
+		name    string
+		results models.Results
+		options Options
+		want    string
+	}{
+		{
+			name: "wide characters",
+			results: models.Results{
+				Columns: []string{"name", "path"},
+				Rows:    models.Rows{{"name": "日本", "path": "/tmp"}, {"name": "a\tb\nc", "path": ""}},
+			},
+			options: Options{Width: -1},
+			want: "----------------\n" +
+				"| name  | path |\n" +
+				"----------------\n" +
+				"| 日本  | /tmp |\n" +
+				"| a b c |      |\n" +
+				"----------------\n",
+		},
+		{
+			name:    "truncated to a narrow terminal",
+			results: wide,
+			options: Options{Width: 20},
+			want: "--------------------\n" +
+				"| name      | path |\n" +
+				"--------------------\n" +
+				"| abcdefgh… | /x   |\n" +
+				"| 日本語テ… | /tmp |\n" +
+				"--------------------\n",
+		},
+		{
+			name:    "wrapped to a narrow terminal",
+			results: wide,
+			options: Options{Width: 20, Overflow: OverflowWrap},
+			want: "--------------------\n" +
+				"| name      | path |\n" +
+				"--------------------\n" +
+				"| abcdefghi | /x   |\n" +
+				"| jkl       |      |\n" +
+				"| 日本語テ  | /tmp |\n" +
+				"| キスト    |      |\n" +
+				"--------------------\n",
+		},
+	}
+	for _, test := range tests {
+		out := bytes.Buffer{}
+		if err := FprintWithOptions(&out, "pretty", test.results, test.options); err != nil {
+			t.Errorf("%s: printing failed: %s", test.name, err)
+			continue
+		}
+		if out.String() != test.want {
+			t.Errorf("%s: printed\n%s\nwant\n%s", test.name, out.String(), test.want)
+		}
+	}
+}
+
+func TestPrettyPrinterFallsBackToLines(t *testing.T) {
+	results := models.Results{
+		Columns: []string{"one", "two", "three", "four"},
+		Rows:    models.Rows{{"one": "1", "two": "2", "three": "3", "four": "4"}},
+	}
+	// Four columns need at least 4 * 3 + 1 characters of borders and 3 + 3 + 5 + 4
+	// of values, so 28 fits exactly and 27 doesn't
+	lines := bytes.Buffer{}
+	if err := Fprint(&lines, "line", results); err != nil {
+		t.Fatalf("Printing in line mode failed: %s", err)
+	}
+	for width, wantLines := range map[int]bool{27: true, 28: false, 0: false} {
+		out := bytes.Buffer{}
+		if err := FprintWithOptions(&out, "pretty", results, Options{Width: width}); err != nil {
+			t.Errorf("Printing at width %d failed: %s", width, err)
+			continue
+		}
+		if gotLines := out.String() == lines.String(); gotLines != wantLines {
+			t.Errorf("Printing at width %d fell back to line mode: %t, want %t\n%s", width, gotLines, wantLines, out.String())
+		}
+	}
+}
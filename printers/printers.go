@@ -0,0 +1,113 @@
+// Package printers is the registry of print modes goquery can format results with.
+// Printers register under the name used by .mode and the printMode config option,
+// usually from an init function so importing a package is enough to make its
+// print modes available.
+package printers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+// Printer formats query results and writes them to w, with columns in the
+// order of results.Columns
+type Printer interface {
+	Print(w io.Writer, results models.Results) error
+}
+
+// PrinterFunc allows a plain function to be registered as a Printer
+type PrinterFunc func(w io.Writer, results models.Results) error
+
+// Print implements Printer
+func (printer PrinterFunc) Print(w io.Writer, results models.Results) error {
+	return printer(w, results)
+}
+
+// Options are the session settings printers may take into account
+type Options struct {
+	// Width is how many terminal columns output may use. Zero means the width
+	// of the terminal being written to, or unlimited when output isn't a
+	// terminal, and a negative width is always unlimited.
+	Width int
+	// Overflow is what the pretty printer does with tables wider than Width,
+	// one of OverflowTruncate (the default) or OverflowWrap
+	Overflow string
+}
+
+// OptionsPrinter is implemented by printers whose output depends on Options.
+// Print is used with the default Options.
+type OptionsPrinter interface {
+	Printer
+	PrintWithOptions(w io.Writer, results models.Results, options Options) error
+}
+
+// DefaultMode is the print mode used when the configured one isn't registered
+const DefaultMode = "pretty"
+
+var mutex sync.Mutex
+var printers = map[string]Printer{}
+
+// Register makes a printer available by name. It panics if the name is already
+// taken, as two printers fighting over a name is a programming error.
+func Register(name string, printer Printer) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if printer == nil {
+		panic("printers: Register printer is nil")
+	}
+	if _, exists := printers[name]; exists {
+		panic("printers: Register called twice for print mode " + name)
+	}
+	printers[name] = printer
+}
+
+// Names returns the sorted names of all registered printers
+func Names() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	names := make([]string, 0, len(printers))
+	for name := range printers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the printer registered under name
+func Get(name string) (Printer, error) {
+	mutex.Lock()
+	printer, ok := printers[name]
+	mutex.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%s is not a valid print mode, available modes: %v", name, Names())
+	}
+	return printer, nil
+}
+
+// Fprint writes results to w with the printer registered under mode, falling back
+// to DefaultMode if there is none
+func Fprint(w io.Writer, mode string, results models.Results) error {
+	return FprintWithOptions(w, mode, results, Options{})
+}
+
+// FprintWithOptions is Fprint passing options to printers that implement OptionsPrinter
+func FprintWithOptions(w io.Writer, mode string, results models.Results, options Options) error {
+	printer, err := Get(mode)
+	if err != nil {
+		printer, err = Get(DefaultMode)
+		if err != nil {
+			return err
+		}
+	}
+	if optionsPrinter, ok := printer.(OptionsPrinter); ok {
+		return optionsPrinter.PrintWithOptions(w, results, options)
+	}
+	return printer.Print(w, results)
+}
@@ -0,0 +1,106 @@
+package printers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+// Print modes meant for pasting into tickets and spreadsheets or piping into
+// other tools. Each row is written with the same columns in the same order,
+// so rows missing a column get an empty value rather than shifting over.
+
+func init() {
+	Register("csv", PrinterFunc(printCSV))
+	Register("tsv", PrinterFunc(printTSV))
+	Register("jsonl", PrinterFunc(printJSONLines))
+	Register("markdown", PrinterFunc(printMarkdown))
+}
+
+func printCSV(w io.Writer, results models.Results) error {
+	return printDelimited(w, results, ',')
+}
+
+func printTSV(w io.Writer, results models.Results) error {
+	return printDelimited(w, results, '\t')
+}
+
+// printDelimited writes a header row followed by every result, quoting fields
+// that contain the delimiter, quotes or new lines
+func printDelimited(w io.Writer, results models.Results, delimiter rune) error {
+	if results.Len() == 0 {
+		return nil
+	}
+	columns := resultColumns(results)
+
+	out := csv.NewWriter(w)
+	out.Comma = delimiter
+	out.Write(columns)
+	record := make([]string, len(columns))
+	for _, row := range results.Rows {
+		for i, column := range columns {
+			record[i] = row[column]
+		}
+		out.Write(record)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// printJSONLines writes one compact JSON object per row
+func printJSONLines(w io.Writer, results models.Results) error {
+	columns := resultColumns(results)
+	out := bufio.NewWriter(w)
+	for _, row := range results.Rows {
+		if err := writeJSONObject(out, columns, row, ""); err != nil {
+			return err
+		}
+		out.WriteString("\n")
+	}
+	return out.Flush()
+}
+
+// printMarkdown writes a GitHub flavoured markdown table
+func printMarkdown(w io.Writer, results models.Results) error {
+	if results.Len() == 0 {
+		return nil
+	}
+	columns := resultColumns(results)
+
+	out := bufio.NewWriter(w)
+	cells := make([]string, len(columns))
+	for i, column := range columns {
+		cells[i] = escapeMarkdownCell(column)
+	}
+	fmt.Fprintf(out, "| %s |\n", strings.Join(cells, " | "))
+	for i := range columns {
+		cells[i] = "---"
+	}
+	fmt.Fprintf(out, "| %s |\n", strings.Join(cells, " | "))
+
+	for _, row := range results.Rows {
+		for i, column := range columns {
+			cells[i] = escapeMarkdownCell(row[column])
+		}
+		fmt.Fprintf(out, "| %s |\n", strings.Join(cells, " | "))
+	}
+	return out.Flush()
+}
+
+var markdownCellReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"|", `\|`,
+	"\r\n", "<br>",
+	"\n", "<br>",
+	"\r", "<br>",
+)
+
+// escapeMarkdownCell keeps a value inside its table cell, pipes would otherwise
+// start a new cell and new lines end the table
+func escapeMarkdownCell(value string) string {
+	return markdownCellReplacer.Replace(value)
+}
@@ -0,0 +1,72 @@
+package printers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+func TestTabularPrinters(t *testing.T) {
+	results := models.Results{
+		Columns: []string{"name", "cmdline"},
+		Rows: models.Rows{
+			{"name": "sh", "cmdline": `sh -c "a, b"`},
+			{"name": "awk", "cmdline": "awk '{print $1 | \"sort\"}'\tx"},
+			{"name": "multi\r\nline", "cmdline": `C:\Windows\`},
+			{"name": "missing"},
+			{"name": "<&>"},
+		},
+	}
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{"csv", "name,cmdline\n" +
+			"sh,\"sh -c \"\"a, b\"\"\"\n" +
+			"awk,\"awk '{print $1 | \"\"sort\"\"}'\tx\"\n" +
+			"\"multi\r\nline\",C:\\Windows\\\n" +
+			"missing,\n" +
+			"<&>,\n"},
+		{"tsv", "name\tcmdline\n" +
+			"sh\t\"sh -c \"\"a, b\"\"\"\n" +
+			"awk\t\"awk '{print $1 | \"\"sort\"\"}'\tx\"\n" +
+			"\"multi\r\nline\"\tC:\\Windows\\\n" +
+			"missing\t\n" +
+			"<&>\t\n"},
+		{"jsonl", `{"name":"sh","cmdline":"sh -c \"a, b\""}` + "\n" +
+			`{"name":"awk","cmdline":"awk '{print $1 | \"sort\"}'\tx"}` + "\n" +
+			`{"name":"multi\r\nline","cmdline":"C:\\Windows\\"}` + "\n" +
+			`{"name":"missing"}` + "\n" +
+			`{"name":"<&>"}` + "\n"},
+		{"markdown", "| name | cmdline |\n" +
+			"| --- | --- |\n" +
+			"| sh | sh -c \"a, b\" |\n" +
+			"| awk | awk '{print $1 \\| \"sort\"}'\tx |\n" +
+			"| multi<br>line | C:\\\\Windows\\\\ |\n" +
+			"| missing |  |\n" +
+			"| <&> |  |\n"},
+	}
+	for _, test := range tests {
+		out := bytes.Buffer{}
+		if err := Fprint(&out, test.mode, results); err != nil {
+			t.Errorf("Printing as %s failed: %s", test.mode, err)
+			continue
+		}
+		if out.String() != test.want {
+			t.Errorf("Printing as %s = %q, want %q", test.mode, out.String(), test.want)
+		}
+	}
+}
+
+func TestTabularPrintersNoRows(t *testing.T) {
+	for _, mode := range []string{"csv", "tsv", "jsonl", "markdown"} {
+		out := bytes.Buffer{}
+		if err := Fprint(&out, mode, models.Results{Columns: []string{"name"}, Rows: models.Rows{}}); err != nil {
+			t.Errorf("Printing no rows as %s failed: %s", mode, err)
+		}
+		if out.Len() != 0 {
+			t.Errorf("Printing no rows as %s = %q, want nothing", mode, out.String())
+		}
+	}
+}
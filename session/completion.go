@@ -0,0 +1,151 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/AbGuthrie/goquery/v2/config"
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/sqlquote"
+)
+
+// completionQueryTimeout bounds the queries listing directories for completion
+const completionQueryTimeout = 2 * time.Minute
+
+// maxCachedDirectories caps the listings kept for completion, the least recently
+// fetched are dropped to make room
+const maxCachedDirectories = 256
+
+// RemoteEntry is a file in a directory on a remote host
+type RemoteEntry struct {
+	Name      string
+	Directory bool
+}
+
+type directoryKey struct {
+	uuid      string
+	directory string
+}
+
+type cachedDirectory struct {
+	entries  []RemoteEntry
+	fetched  time.Time
+	fetching bool
+}
+
+// directoryCache keeps the directory listings fetched for completion
+type directoryCache struct {
+	mutex       sync.Mutex
+	directories map[directoryKey]*cachedDirectory
+}
+
+// DirectoryEntries returns the files in a directory on a host for completing
+// paths. Listings are cached per host and directory for the config's
+// RemoteCompletionTTL. A listing that isn't cached or has expired is fetched in
+// the background, returning nothing or the expired listing meanwhile, so
+// completion never waits on the backend. Nothing is fetched when remote
+// completion is disabled, and fetching never logs in to the backend, so nothing is
+// listed until a command has.
+func (session *Session) DirectoryEntries(uuid, directory string) []RemoteEntry {
+	if session.Config.DisableRemoteCompletion {
+		return nil
+	}
+	ttl := time.Duration(session.Config.RemoteCompletionTTL) * time.Second
+	if ttl <= 0 {
+		ttl = config.DefaultRemoteCompletionTTL * time.Second
+	}
+
+	cache := &session.directories
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.directories == nil {
+		cache.directories = map[directoryKey]*cachedDirectory{}
+	}
+	key := directoryKey{uuid: uuid, directory: directory}
+	cached, ok := cache.directories[key]
+	if !ok {
+		if len(cache.directories) >= maxCachedDirectories {
+			cache.evictOldest()
+		}
+		cached = &cachedDirectory{}
+		cache.directories[key] = cached
+	}
+	if !cached.fetching && time.Since(cached.fetched) > ttl {
+		cached.fetching = true
+		go session.fetchDirectory(key, cached)
+	}
+	return cached.entries
+}
+
+// evictOldest drops the least recently fetched listing that isn't being fetched.
+// The cache's mutex must be held.
+func (cache *directoryCache) evictOldest() {
+	var oldest directoryKey
+	found := false
+	for key, cached := range cache.directories {
+		if cached.fetching {
+			continue
+		}
+		if !found || cached.fetched.Before(cache.directories[oldest].fetched) {
+			oldest = key
+			found = true
+		}
+	}
+	if found {
+		delete(cache.directories, oldest)
+	}
+}
+
+// fetchDirectory refreshes a cached listing. Failures keep the previous listing
+// until the TTL passes again, so a broken host isn't queried on every keystroke.
+// A backend that isn't logged in is tried again on the next keystroke, as that
+// makes no request.
+func (session *Session) fetchDirectory(key directoryKey, cached *cachedDirectory) {
+	ctx, cancel := context.WithTimeout(models.WithoutAuthentication(context.Background()), completionQueryTimeout)
+	defer cancel()
+	entries, err := session.listRemoteDirectory(ctx, key.uuid, key.directory)
+
+	session.directories.mutex.Lock()
+	defer session.directories.mutex.Unlock()
+	cached.fetching = false
+	if errors.Is(err, models.ErrNotAuthenticated) {
+		return
+	}
+	cached.fetched = time.Now()
+	if err == nil {
+		cached.entries = entries
+	}
+}
+
+// listRemoteDirectory queries a directory's files straight through the API, so
+// completing leaves no trace in the host's query history or the local results
+func (session *Session) listRemoteDirectory(ctx context.Context, uuid, directory string) ([]RemoteEntry, error) {
+	query := "select filename, type from file where directory = " + sqlquote.String(directory)
+	queryName, err := session.API.ScheduleQueryContext(ctx, uuid, query)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		rows, status, err := session.API.FetchResultsContext(ctx, queryName)
+		if err != nil {
+			return nil, err
+		}
+		if !status.Pending() {
+			if err := status.Err(); err != nil {
+				return nil, err
+			}
+			entries := make([]RemoteEntry, 0, len(rows))
+			for _, row := range rows {
+				entries = append(entries, RemoteEntry{Name: row["filename"], Directory: row["type"] == "directory"})
+			}
+			return entries, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package session
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/utils"
+)
+
+// Redirect sends command output to w instead of Out until the returned function is
+// called to restore it. Redirected output is not mirrored to the tee file.
+func (session *Session) Redirect(w io.Writer) func() {
+	out, tee := session.Out, session.tee
+	session.Out, session.tee = w, nil
+	return func() {
+		session.Out, session.tee = out, tee
+	}
+}
+
+// StartTee mirrors all following command output to the file at filePath, in the
+// current print mode, either appending to it or replacing its contents. Any file
+// output was already being mirrored to is closed.
+func (session *Session) StartTee(filePath string, appendOutput bool) error {
+	file, err := utils.OpenOutputFile(filePath, appendOutput)
+	if err != nil {
+		return err
+	}
+	if _, err := session.StopTee(); err != nil {
+		file.Close()
+		return err
+	}
+	session.tee, session.teePath = file, filePath
+	return nil
+}
+
+// StopTee stops mirroring output, returning the path of the file it was mirrored to
+func (session *Session) StopTee() (string, error) {
+	if session.tee == nil {
+		return "", nil
+	}
+	filePath := session.teePath
+	err := session.tee.Close()
+	session.tee, session.teePath = nil, ""
+	if err != nil {
+		return filePath, fmt.Errorf("Could not close %s: %s", filePath, err)
+	}
+	return filePath, nil
+}
+
+// TeePath returns the file output is being mirrored to, or "" when it isn't
+func (session *Session) TeePath() string {
+	return session.teePath
+}
+
+// pipe is a transformation applied to results before they are printed
+type pipe struct {
+	transform func(models.Results) (models.Results, error)
+	err       error
+}
+
+// Pipe passes results printed with PrintResults through transform until the
+// returned function is called. That function returns the first error transform
+// failed with, in which case the results were not printed. Pipes set while
+// another is in place run first.
+func (session *Session) Pipe(transform func(models.Results) (models.Results, error)) func() error {
+	added := &pipe{transform: transform}
+	session.pipes = append(session.pipes, added)
+	return func() error {
+		for i, existing := range session.pipes {
+			if existing == added {
+				session.pipes = append(session.pipes[:i], session.pipes[i+1:]...)
+				break
+			}
+		}
+		return added.err
+	}
+}
+
+// applyPipes runs results through the current pipes, most recently added first
+func (session *Session) applyPipes(results models.Results) (models.Results, bool) {
+	for i := len(session.pipes) - 1; i >= 0; i-- {
+		var err error
+		if results, err = session.pipes[i].transform(results); err != nil {
+			session.pipes[i].err = err
+			return results, false
+		}
+	}
+	return results, true
+}
+
+// Capture keeps the results of PrintResults instead of printing them until the
+// returned function is called, which returns the last results kept and whether
+// there were any
+func (session *Session) Capture() func() (models.Results, bool) {
+	previous := session.capture
+	captured := &capture{}
+	session.capture = captured
+	return func() (models.Results, bool) {
+		session.capture = previous
+		return captured.results, captured.ok
+	}
+}
+
+// capture holds results kept by Capture
+type capture struct {
+	results models.Results
+	ok      bool
+}
@@ -0,0 +1,272 @@
+// Package session holds the state of a single goquery shell: the backend it
+// talks to, its config, the hosts it is connected to, its scheduled queries
+// and where its history and output go. Embedding applications can create as
+// many sessions as they like and use them concurrently.
+package session
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/AbGuthrie/goquery/v2/config"
+	"github.com/AbGuthrie/goquery/v2/hosts"
+	"github.com/AbGuthrie/goquery/v2/jobs"
+	"github.com/AbGuthrie/goquery/v2/localdb"
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/pager"
+	"github.com/AbGuthrie/goquery/v2/printers"
+	"github.com/AbGuthrie/goquery/v2/utils"
+)
+
+// Session is the state commands run against
+type Session struct {
+	API    models.GoQueryContextAPI
+	Config *config.Config
+	Hosts  *hosts.List
+	Jobs   *jobs.Manager
+	// Local holds the results fetched in the session for querying with .local
+	Local *localdb.DB
+	// HistoryPath is the file commands are recorded to, history is not
+	// kept when it is empty
+	HistoryPath string
+	// Out receives command output, Err receives progress and diagnostics so
+	// Out can be piped when running non interactively
+	Out io.Writer
+	Err io.Writer
+
+	// tee receives a copy of command output while .tee is on
+	tee     io.WriteCloser
+	teePath string
+	// pipes transform results before they are printed, see Pipe
+	pipes []*pipe
+	// capture keeps results instead of printing them, see Capture
+	capture *capture
+	// variables are results kept for later commands to refer to
+	variables map[string]models.Results
+	// directories caches remote directory listings, see DirectoryEntries
+	directories directoryCache
+}
+
+// New creates a session with its own empty host list, loading host groups
+// from the config
+func New(api models.GoQueryAPI, cfg config.Config) *Session {
+	return NewWithHosts(api, cfg, hosts.NewList())
+}
+
+// NewWithHosts creates a session that shares an existing host list, for
+// example hosts.DefaultList for code still using the package level hosts API
+func NewWithHosts(api models.GoQueryAPI, cfg config.Config, hostList *hosts.List) *Session {
+	hostList.LoadGroups(cfg.HostGroups)
+	return &Session{
+		API:         models.WithContext(api),
+		Config:      &cfg,
+		Hosts:       hostList,
+		Jobs:        jobs.NewManager(),
+		Local:       localdb.New(),
+		HistoryPath: utils.DefaultHistoryPath(),
+		Out:         os.Stdout,
+		Err:         os.Stderr,
+	}
+}
+
+// Printf writes formatted command output to Out, and the tee file if there is one
+func (session *Session) Printf(format string, a ...interface{}) {
+	fmt.Fprintf(session.Out, format, a...)
+	if session.tee != nil {
+		fmt.Fprintf(session.tee, format, a...)
+	}
+}
+
+// PrintResults writes results to Out in the configured print mode, through the
+// pager if they don't fit on screen, reporting any failure to Err. The tee file,
+// if there is one, gets them in full in the same print mode. Results are first
+// passed through any pipes set with Pipe.
+func (session *Session) PrintResults(results models.Results) {
+	session.printResults(results, false)
+}
+
+// PrintQueryResults prints the results of a query on a host like PrintResults,
+// and keeps them as the last variable
+func (session *Session) PrintQueryResults(results models.Results) {
+	session.printResults(results, true)
+}
+
+func (session *Session) printResults(results models.Results, last bool) {
+	results, ok := session.applyPipes(results)
+	if !ok {
+		return
+	}
+	if last {
+		session.SetVariable(LastVariable, results)
+	}
+	if session.capture != nil {
+		session.capture.results, session.capture.ok = results, true
+		return
+	}
+	mode := string(session.Config.PrintMode)
+	if err := pager.Fprint(session.Out, mode, results, session.Config.PrintOptions(), session.Config.Pager); err != nil {
+		fmt.Fprintf(session.Err, "Could not print results: %s\n", err)
+	}
+	session.teeResults(results)
+}
+
+// RenderResults handles results like PrintResults, but returns them formatted for
+// Out rather than printing them, for commands like .watch that redraw their
+// output. Results are never paged, and ok is false if a pipe failed.
+func (session *Session) RenderResults(results models.Results) (string, bool) {
+	results, ok := session.applyPipes(results)
+	if !ok {
+		return "", false
+	}
+
+	options := session.Config.PrintOptions()
+	if options.Width == 0 {
+		options.Width = printers.TerminalWidth(session.Out)
+	}
+	rendered := bytes.Buffer{}
+	if err := printers.FprintWithOptions(&rendered, string(session.Config.PrintMode), results, options); err != nil {
+		fmt.Fprintf(session.Err, "Could not print results: %s\n", err)
+	}
+	session.teeResults(results)
+	return rendered.String(), true
+}
+
+// teeResults writes results in full to the tee file, if there is one
+func (session *Session) teeResults(results models.Results) {
+	if session.tee == nil {
+		return
+	}
+	mode := string(session.Config.PrintMode)
+	if err := printers.FprintWithOptions(session.tee, mode, results, session.Config.PrintOptions()); err != nil {
+		fmt.Fprintf(session.Err, "Could not write results to %s: %s\n", session.teePath, err)
+	}
+}
+
+// ScheduleQuery schedules query on a host and records it in the host's query history
+func (session *Session) ScheduleQuery(ctx context.Context, uuid, query string) (string, error) {
+	queryName, err := session.API.ScheduleQueryContext(ctx, uuid, query)
+	if err != nil {
+		return "", err
+	}
+	// The host may have been disconnected while the query was being scheduled,
+	// in which case there is no history left to add to
+	session.Hosts.AddQueryToHost(uuid, hosts.Query{Name: queryName, SQL: query})
+	return queryName, nil
+}
+
+// FetchResults fetches the results of a scheduled query. Columns are in the order
+// the backend returned them when it implements models.OrderedResultsAPI, otherwise
+// they follow the SELECT list of the query's SQL if the session scheduled it.
+// Complete results are also stored in Local under the query's name.
+func (session *Session) FetchResults(ctx context.Context, queryName string) (models.Results, models.QueryStatus, error) {
+	host, querySQL := session.queryOrigin(queryName)
+	results, status, err := session.fetchResults(ctx, queryName, querySQL)
+	if err == nil && status.State == models.QueryComplete {
+		if storeErr := session.Local.Store(queryName, host, querySQL, results); storeErr != nil && session.Config.DebugEnabled {
+			fmt.Fprintf(session.Err, "Could not store results locally: %s\n", storeErr)
+		}
+	}
+	return results, status, err
+}
+
+// fetchResults fetches the results of the query named queryName that ran querySQL,
+// without storing them
+func (session *Session) fetchResults(ctx context.Context, queryName, querySQL string) (models.Results, models.QueryStatus, error) {
+	if ordered, ok := models.Unwrap(session.API).(models.OrderedResultsAPI); ok {
+		results, status, err := ordered.FetchOrderedResultsContext(ctx, queryName)
+		if err == nil && len(results.Columns) == 0 {
+			results = models.NewResultsForQuery(querySQL, results.Rows)
+		}
+		return results, status, err
+	}
+	rows, status, err := session.API.FetchResultsContext(ctx, queryName)
+	return models.NewResultsForQuery(querySQL, rows), status, err
+}
+
+// queryOrigin looks up the host and SQL of a query in the connected hosts' histories
+func (session *Session) queryOrigin(queryName string) (string, string) {
+	for _, host := range session.Hosts.GetCurrentHosts() {
+		for _, query := range host.QueryHistory {
+			if query.Name == queryName {
+				return host.ComputerName, query.SQL
+			}
+		}
+	}
+	return "", ""
+}
+
+// WaitForResults polls the backend until the named query is no longer pending or
+// ctx is cancelled. progress is called each time the query is still pending.
+func (session *Session) WaitForResults(ctx context.Context, queryName string, progress func()) (models.Results, error) {
+	return waitForResults(ctx, progress, func() (models.Results, models.QueryStatus, error) {
+		return session.FetchResults(ctx, queryName)
+	})
+}
+
+// waitForResults calls fetch every second until the query is no longer pending
+func waitForResults(ctx context.Context, progress func(),
+	fetch func() (models.Results, models.QueryStatus, error)) (models.Results, error) {
+	for {
+		results, status, err := fetch()
+		if err != nil {
+			return results, err
+		}
+		if !status.Pending() {
+			return results, status.Err()
+		}
+		select {
+		case <-ctx.Done():
+			return results, fmt.Errorf("Waiting Cancelled: %s", ctx.Err())
+		case <-time.After(time.Second):
+		}
+		progress()
+	}
+}
+
+// QueryUnrecorded schedules query on a host and blocks until results are available
+// or ctx is cancelled, like ScheduleQueryAndWait, but leaves no trace in the host's
+// query history or Local. It is meant for queries that are run over and over.
+func (session *Session) QueryUnrecorded(ctx context.Context, uuid, query string) (models.Results, error) {
+	queryName, err := session.API.ScheduleQueryContext(ctx, uuid, query)
+	if err != nil {
+		return models.Results{}, fmt.Errorf("Could not schedule query: %s", err)
+	}
+	return waitForResults(ctx, func() {}, func() (models.Results, models.QueryStatus, error) {
+		return session.fetchResults(ctx, queryName, query)
+	})
+}
+
+// ScheduleQueryAndWait schedules query on a host and blocks until results are available
+// or ctx is cancelled, printing progress to Err
+func (session *Session) ScheduleQueryAndWait(ctx context.Context, uuid, query string) (models.Results, error) {
+	queryName, err := session.ScheduleQuery(ctx, uuid, query)
+	if err != nil {
+		return models.Results{}, fmt.Errorf("ScheduleQueryAndWait call failed: %s", err)
+	}
+
+	results, err := session.WaitForResults(ctx, queryName, func() {
+		fmt.Fprintf(session.Err, ".")
+	})
+	fmt.Fprintf(session.Err, "\n")
+	return results, err
+}
+
+// LoadHistory reads the session's history file
+func (session *Session) LoadHistory() ([]string, error) {
+	if session.HistoryPath == "" {
+		return []string{}, nil
+	}
+	return utils.ReadHistory(session.HistoryPath)
+}
+
+// AppendHistory records a line of input in the session's history file
+func (session *Session) AppendHistory(line string) error {
+	if session.HistoryPath == "" {
+		return nil
+	}
+	return utils.AppendHistory(session.HistoryPath, line)
+}
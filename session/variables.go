@@ -0,0 +1,46 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+// LastVariable is the variable holding the results of the most recent query, see
+// PrintQueryResults
+const LastVariable = "last"
+
+// SetVariable stores results under name, for later commands to refer to as
+// ${name[row].column}
+func (session *Session) SetVariable(name string, results models.Results) {
+	if session.variables == nil {
+		session.variables = map[string]models.Results{}
+	}
+	session.variables[name] = results
+}
+
+// Variable returns the results stored under name
+func (session *Session) Variable(name string) (models.Results, bool) {
+	results, ok := session.variables[name]
+	return results, ok
+}
+
+// RemoveVariable deletes the variable called name
+func (session *Session) RemoveVariable(name string) error {
+	if _, ok := session.variables[name]; !ok {
+		return fmt.Errorf("Variable '%s' not found", name)
+	}
+	delete(session.variables, name)
+	return nil
+}
+
+// VariableNames returns the sorted names of the session's variables
+func (session *Session) VariableNames() []string {
+	names := make([]string, 0, len(session.variables))
+	for name := range session.variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
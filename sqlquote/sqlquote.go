@@ -0,0 +1,32 @@
+// Package sqlquote builds the pieces of SQL that goquery's commands fill in from
+// user input, like paths, quoting them so a value containing a quote can't end
+// its string early and change what the query does. External commands building
+// queries from their arguments should use it too.
+package sqlquote
+
+import (
+	"strings"
+)
+
+// String quotes value as an SQL string literal, doubling any single quotes in it.
+// LIKE patterns are quoted with it too, leaving % and _ as wildcards, as osquery's
+// file table expands path patterns itself and doesn't understand LIKE escapes.
+func String(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
+
+// List quotes values as a parenthesised list of string literals for IN, as in
+// ('a', 'b')
+func List(values ...string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = String(value)
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// Identifier quotes name as an SQL identifier, for table and column names that
+// aren't plain words
+func Identifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
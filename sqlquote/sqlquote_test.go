@@ -0,0 +1,127 @@
+package sqlquote
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+var values = []string{
+	"",
+	"/etc/passwd",
+	"it's",
+	"''",
+	"'; drop table users; --",
+	`C:\Users\Bob\`,
+	`"quoted"`,
+	"100%_done",
+	`\%`,
+	"/Users/Zoë/Library/日本語/ファイル.txt",
+	"emoji 🔥 and combining é",
+}
+
+func openDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestString(t *testing.T) {
+	tests := map[string]string{
+		"":      "''",
+		"plain": "'plain'",
+		"it's":  "'it''s'",
+		"''":    "''''''",
+		"Zoë":   "'Zoë'",
+	}
+	for value, want := range tests {
+		if got := String(value); got != want {
+			t.Errorf("String(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	db := openDB(t)
+	for _, value := range values {
+		var got string
+		if err := db.QueryRow("select " + String(value)).Scan(&got); err != nil {
+			t.Errorf("select %s: %s", String(value), err)
+			continue
+		}
+		if got != value {
+			t.Errorf("select %s = %q, want %q", String(value), got, value)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	if got, want := List("a", "b's"), "('a', 'b''s')"; got != want {
+		t.Errorf("List = %s, want %s", got, want)
+	}
+
+	db := openDB(t)
+	var count int
+	query := "select count(*) from (select 'it''s' as value union all select 'other') where value in " + List(values...)
+	if err := db.QueryRow(query).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("%s matched %d rows, want 1", query, count)
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	if got, want := Identifier(`odd "name"`), `"odd ""name"""`; got != want {
+		t.Errorf("Identifier = %s, want %s", got, want)
+	}
+
+	db := openDB(t)
+	for _, name := range []string{"pid", `odd "name"`, "it's", "名前", "select"} {
+		if _, err := db.Exec("create table " + Identifier(name) + " (" + Identifier(name) + ")"); err != nil {
+			t.Errorf("create table %s: %s", Identifier(name), err)
+		}
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	db := openDB(t)
+	if _, err := db.Exec("create table files (name)"); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"100%_done.txt", "100xydone.txt", "Zoë's notes", "Zoe's notes", "日本語.txt"}
+	for _, name := range names {
+		if _, err := db.Exec("insert into files values (" + String(name) + ")"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Quoting leaves the wildcards in patterns working
+	tests := []struct {
+		pattern string
+		want    int
+	}{
+		{"100%_done.txt", 2},
+		{`100\%`, 0},
+		{"Zo_'s%", 2},
+		{"Zoë's%", 1},
+		{"%'s notes", 2},
+		{"日本_.txt", 1},
+	}
+	for _, test := range tests {
+		var count int
+		query := "select count(*) from files where name like " + String(test.pattern)
+		if err := db.QueryRow(query).Scan(&count); err != nil {
+			t.Errorf("%s: %s", query, err)
+			continue
+		}
+		if count != test.want {
+			t.Errorf("%s matched %d names, want %d", query, count, test.want)
+		}
+	}
+}
@@ -3,34 +3,78 @@ package utils
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
-// InterpolateArguments fills in an alias' placeholders ($#) with provided arguments
-// TODO add alias_test.go unit tests
+// placeholder matches what commands have filled in: the $# placeholders of aliases
+// and references to result variables, ${name}, ${name.column}, ${name[row].column},
+// ${name[*].column} and the plain $name, each along with a backslash escaping the $
+var placeholder = regexp.MustCompile(
+	`\\?\$(?:(#)|\{([A-Za-z_][A-Za-z0-9_]*)(?:\[(-?[0-9]+|\*)\])?(?:\.([^}\s]+))?\}|([A-Za-z_][A-Za-z0-9_]*)\b)`)
+
+// placeholderMatch is a placeholder found by interpolate
+type placeholderMatch struct {
+	// argument is set for an alias' $#, the other fields for variable references
+	argument bool
+	name     string
+	row      string
+	column   string
+	// bare is set for the $name form
+	bare bool
+}
+
+// interpolate replaces the placeholders in rawLine that known accepts with their
+// value. An accepted placeholder escaped with a backslash is left as is, without
+// the backslash, and the first error from value is returned.
+func interpolate(rawLine string, known func(placeholderMatch) bool,
+	value func(placeholderMatch) (string, error)) (string, error) {
+	var interpolateErr error
+	interpolated := placeholder.ReplaceAllStringFunc(rawLine, func(reference string) string {
+		if interpolateErr != nil {
+			return reference
+		}
+		parts := placeholder.FindStringSubmatch(reference)
+		match := placeholderMatch{argument: parts[1] != "", name: parts[2], row: parts[3], column: parts[4]}
+		if parts[5] != "" {
+			match.name, match.bare = parts[5], true
+		}
+		if !known(match) {
+			return reference
+		}
+		if strings.HasPrefix(reference, `\`) {
+			return reference[1:]
+		}
+		replacement, err := value(match)
+		if err != nil {
+			interpolateErr = fmt.Errorf("%s: %s", reference, err)
+			return reference
+		}
+		return replacement
+	})
+	return interpolated, interpolateErr
+}
+
+// InterpolateArguments fills in an alias' placeholders ($#) with provided arguments,
+// in order. \$# is left as a literal $#.
 func InterpolateArguments(rawLine string, command string) (string, error) {
 	inputParts := strings.Split(rawLine, " ")
 	args := inputParts[1:]
 
-	// TODO this should support escaping and ignoring the
-	// placeholder pattern ie \$#
-	placeholderParts := strings.Split(command, "$#")
+	placeholders := 0
+	realizedCommand, _ := interpolate(command, func(match placeholderMatch) bool {
+		return match.argument
+	}, func(placeholderMatch) (string, error) {
+		placeholders++
+		if placeholders > len(args) {
+			return "", nil
+		}
+		return args[placeholders-1], nil
+	})
 
 	// Assert arguments provided and placeholders align
-	if len(args) != len(placeholderParts)-1 {
-		return "", fmt.Errorf("Argument mismatch, alias expects %d args", len(placeholderParts)-1)
+	if len(args) != placeholders {
+		return "", fmt.Errorf("Argument mismatch, alias expects %d args", placeholders)
 	}
-
-	// If no placeholders in query, return as is
-	if len(placeholderParts)-1 == 0 {
-		return command, nil
-	}
-
-	realizedCommand := ""
-	for i, arg := range args {
-		realizedCommand += placeholderParts[i]
-		realizedCommand += arg
-	}
-
 	return realizedCommand, nil
 }
@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func TestInterpolateArguments(t *testing.T) {
+	tests := []struct {
+		input   string
+		command string
+		want    string
+	}{
+		{".all", ".query select * from processes", ".query select * from processes"},
+		{".all processes", ".query select * from $#", ".query select * from processes"},
+		{".pid 42", ".query select * from processes where pid = $# limit 1", ".query select * from processes where pid = 42 limit 1"},
+		{".two users 10", ".query select * from $# limit $#", ".query select * from users limit 10"},
+		{".price 5", `.query select '\$#' || $#`, ".query select '$#' || 5"},
+		{".pid 42", ".query select * from processes where pid = $# and uid = ${last}", ".query select * from processes where pid = 42 and uid = ${last}"},
+	}
+	for _, test := range tests {
+		got, err := InterpolateArguments(test.input, test.command)
+		if err != nil {
+			t.Errorf("InterpolateArguments(%q, %q) failed: %s", test.input, test.command, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("InterpolateArguments(%q, %q) = %q, want %q", test.input, test.command, got, test.want)
+		}
+	}
+}
+
+func TestInterpolateArgumentsMismatch(t *testing.T) {
+	tests := []struct {
+		input   string
+		command string
+	}{
+		{".all", ".query select * from $#"},
+		{".all processes users", ".query select * from $#"},
+		{".all processes", ".query select * from processes"},
+	}
+	for _, test := range tests {
+		if got, err := InterpolateArguments(test.input, test.command); err == nil {
+			t.Errorf("InterpolateArguments(%q, %q) = %q, want an error", test.input, test.command, got)
+		}
+	}
+}
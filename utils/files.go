@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandHome replaces a leading ~ in filePath with the user's home directory
+func ExpandHome(filePath string) string {
+	if filePath != "~" && !strings.HasPrefix(filePath, "~/") {
+		return filePath
+	}
+	usr, err := user.Current()
+	if err != nil {
+		return filePath
+	}
+	return filepath.Join(usr.HomeDir, filePath[1:])
+}
+
+// OpenOutputFile opens filePath for command output, creating it if needed and
+// either appending to or truncating any existing contents
+func OpenOutputFile(filePath string, appendOutput bool) (*os.File, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendOutput {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	return os.OpenFile(ExpandHome(filePath), flags, 0644)
+}
@@ -32,10 +32,27 @@ func init() {
 	}
 }
 
+// DefaultHistoryPath returns the history file shared by sessions that don't
+// set their own, ~/.goquery/history
+func DefaultHistoryPath() string {
+	return historyPath
+}
+
 // LoadHistoryFile attempts to read and return the history file
 // from disk and parse it as new line delimited commands
 func LoadHistoryFile() ([]string, error) {
-	historyBytes, err := ioutil.ReadFile(historyPath)
+	return ReadHistory(historyPath)
+}
+
+// UpdateHistoryFile attempts to write a new line entry
+// to the history file
+func UpdateHistoryFile(line string) error {
+	return AppendHistory(historyPath, line)
+}
+
+// ReadHistory reads the history file at filePath as new line delimited commands
+func ReadHistory(filePath string) ([]string, error) {
+	historyBytes, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		return []string{}, err
 	}
@@ -43,20 +60,20 @@ func LoadHistoryFile() ([]string, error) {
 	return lines, nil
 }
 
-// UpdateHistoryFile attempts to write a new line entry
-// to the history file
-func UpdateHistoryFile(line string) error {
+// AppendHistory writes a new line entry to the history file at filePath,
+// creating it if needed
+func AppendHistory(filePath, line string) error {
 	// If history file is empty, don't prepend \n to entry
 	newline := "\n"
-	historyBytes, err := ioutil.ReadFile(historyPath)
-	if err != nil {
+	historyBytes, err := ioutil.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 	if len(historyBytes) == 0 {
 		newline = ""
 	}
 	// Write line entry to history file
-	historyFile, err := os.OpenFile(historyPath, os.O_APPEND|os.O_WRONLY, 0644)
+	historyFile, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	defer historyFile.Close()
 	if err != nil {
 		return err
@@ -1,42 +1,46 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/signal"
 	"time"
 
 	"github.com/AbGuthrie/goquery/v2/models"
 )
 
-// ScheduleQueryAndWait schedules the provided query with the proved API, and implements blocking
-// with a ctrl C interupt
-func ScheduleQueryAndWait(api models.GoQueryAPI, uuid, query string) (models.Rows, error) {
-	ctrlcChannel := make(chan os.Signal, 1)
-	signal.Notify(ctrlcChannel, os.Interrupt)
-	results := make([]map[string]string, 0)
-	queryName, err := api.ScheduleQuery(uuid, query)
+// ScheduleQueryAndWait schedules the provided query with the proved API, and blocks until
+// results are available or the context is cancelled (for example by ctrl C or a timeout)
+func ScheduleQueryAndWait(ctx context.Context, api models.GoQueryContextAPI, uuid, query string) (models.Rows, error) {
+	queryName, err := api.ScheduleQueryContext(ctx, uuid, query)
 	if err != nil {
-		return results, fmt.Errorf("ScheduleQueryAndWait call failed: %s", err)
+		return make([]map[string]string, 0), fmt.Errorf("ScheduleQueryAndWait call failed: %s", err)
 	}
 
-	// Wait while the query is pending
-	var status string
+	// Progress goes to stderr so results on stdout can be piped when run non interactively
+	results, err := WaitForResults(ctx, api, queryName, func() {
+		fmt.Fprintf(os.Stderr, ".")
+	})
+	fmt.Fprintf(os.Stderr, "\n")
+	return results, err
+}
+
+// WaitForResults polls the API until the named query is no longer pending or the context
+// is cancelled. progress is called each time the query is found to still be pending.
+func WaitForResults(ctx context.Context, api models.GoQueryContextAPI, queryName string, progress func()) (models.Rows, error) {
 	for {
-		results, status, err = api.FetchResults(queryName)
-		if err != nil || status != "Pending" {
-			break
+		results, status, err := api.FetchResultsContext(ctx, queryName)
+		if err != nil {
+			return results, err
+		}
+		if !status.Pending() {
+			return results, status.Err()
 		}
-		time.Sleep(time.Second)
-		fmt.Printf(".")
 		select {
-		case <-ctrlcChannel:
-			return results, fmt.Errorf("Waiting Cancelled")
-		default:
+		case <-ctx.Done():
+			return results, fmt.Errorf("Waiting Cancelled: %s", ctx.Err())
+		case <-time.After(time.Second):
 		}
+		progress()
 	}
-
-	fmt.Printf("\n")
-	// No need to check error here because return is the same
-	return results, err
 }
@@ -2,19 +2,24 @@
 package utils
 
 import (
+	"io"
+	"os"
+
 	"github.com/AbGuthrie/goquery/v2/config"
 	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/pager"
+	"github.com/AbGuthrie/goquery/v2/printers"
 )
 
 // PrettyPrintQueryResults prints a given []result map set to standard out
-// taking into consideration the current state.go's print mode
+// taking into consideration the current state.go's print mode, through the
+// pager when it is taller than the terminal
 func PrettyPrintQueryResults(results models.Rows, printMode config.PrintModeEnum) {
-	switch printMode {
-	case config.PrintJSON:
-		prettyPrintQueryResultsJSON(results)
-	case config.PrintLine:
-		prettyPrintQueryResultsLines(results)
-	default:
-		prettyPrintQueryResultsPretty(results)
-	}
+	pager.Fprint(os.Stdout, string(printMode), models.NewResults(results), printers.Options{}, pager.Auto)
+}
+
+// FprintQueryResults prints a given []result map set to w with the printer
+// registered for printMode. As Rows have no column order, columns are sorted.
+func FprintQueryResults(w io.Writer, results models.Rows, printMode config.PrintModeEnum) error {
+	return printers.Fprint(w, string(printMode), models.NewResults(results))
 }
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+	"github.com/AbGuthrie/goquery/v2/sqlquote"
+)
+
+// plainNumber matches values that are safe to insert into SQL unquoted
+var plainNumber = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// InterpolateVariables fills in references to result variables in rawLine with
+// their values, looking variables up with lookup. ${name[row].column} is a
+// single value, rows counting from 0 and negative rows counting back from the
+// end, and ${name.column} is the first row's. ${name[*].column} is every row's
+// value separated by commas, for use in an IN list. Values are quoted as SQL
+// strings unless they are plain numbers. The column can be left out of results
+// with a single column, and \${ is left as is. $name is short for ${name}, but
+// only for variables that exist, so a $ in SQL or a regular expression is left
+// alone.
+func InterpolateVariables(rawLine string, lookup func(name string) (models.Results, bool)) (string, error) {
+	return interpolate(rawLine, func(match placeholderMatch) bool {
+		if match.argument {
+			return false
+		}
+		if match.bare {
+			_, ok := lookup(match.name)
+			return ok
+		}
+		return true
+	}, func(match placeholderMatch) (string, error) {
+		return variableValue(match.name, match.row, match.column, lookup)
+	})
+}
+
+// quoteValue quotes value for SQL unless it is a plain number
+func quoteValue(value string) string {
+	if plainNumber.MatchString(value) {
+		return value
+	}
+	return sqlquote.String(value)
+}
+
+func variableValue(name, row, column string, lookup func(name string) (models.Results, bool)) (string, error) {
+	results, ok := lookup(name)
+	if !ok {
+		return "", fmt.Errorf("No variable named %s, set one with .let", name)
+	}
+
+	if column == "" {
+		if len(results.Columns) != 1 {
+			return "", fmt.Errorf("%s has columns %s, pick one with ${%s[0].COLUMN}",
+				name, strings.Join(results.Columns, ", "), name)
+		}
+		column = results.Columns[0]
+	}
+	matched := ""
+	for _, existing := range results.Columns {
+		if strings.EqualFold(existing, column) {
+			matched = existing
+		}
+	}
+	if matched == "" {
+		return "", fmt.Errorf("%s has no column %s, columns are: %s", name, column, strings.Join(results.Columns, ", "))
+	}
+
+	if row == "*" {
+		values := make([]string, 0, results.Len())
+		for _, result := range results.Rows {
+			values = append(values, quoteValue(result[matched]))
+		}
+		return strings.Join(values, ", "), nil
+	}
+
+	index := 0
+	if row != "" {
+		index, _ = strconv.Atoi(row)
+	}
+	if index < 0 {
+		index += results.Len()
+	}
+	if index < 0 || index >= results.Len() {
+		return "", fmt.Errorf("Row %s is out of range, %s has %d row(s)", row, name, results.Len())
+	}
+	return quoteValue(results.Rows[index][matched]), nil
+}
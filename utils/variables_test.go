@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/AbGuthrie/goquery/v2/models"
+)
+
+var testVariables = map[string]models.Results{
+	"mixed": {
+		Columns: []string{"value"},
+		Rows:    models.Rows{{"value": "1.5"}, {"value": "-2"}, {"value": "1e3"}, {"value": "Inf"}, {"value": ""}},
+	},
+	"procs": {
+		Columns: []string{"pid", "name"},
+		Rows: models.Rows{
+			{"pid": "1", "name": "init"},
+			{"pid": "42", "name": "sshd"},
+			{"pid": "1337", "name": "it's"},
+		},
+	},
+	"last": {
+		Columns: []string{"uid"},
+		Rows:    models.Rows{{"uid": "501"}, {"uid": "0"}},
+	},
+	"empty": {Columns: []string{"path"}, Rows: models.Rows{}},
+}
+
+func lookupTestVariable(name string) (models.Results, bool) {
+	results, ok := testVariables[name]
+	return results, ok
+}
+
+func TestInterpolateVariables(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"select 1", "select 1"},
+		{"where pid = ${procs.pid}", "where pid = 1"},
+		{"where pid = ${procs[1].pid}", "where pid = 42"},
+		{"where pid = ${procs[-1].pid}", "where pid = 1337"},
+		{"where pid = ${procs[-3].PID}", "where pid = 1"},
+		{"where pid in (${procs[*].pid})", "where pid in (1, 42, 1337)"},
+		{"where name = ${procs[1].name}", "where name = 'sshd'"},
+		{"where name = ${procs[-1].name}", "where name = 'it''s'"},
+		{"where name = ${procs.name} or 1", "where name = 'init' or 1"},
+		{"where name in (${procs[*].name})", "where name in ('init', 'sshd', 'it''s')"},
+		{"where path in (${empty[*].path})", "where path in ()"},
+		{"where value in (${mixed[*]})", "where value in (1.5, -2, '1e3', 'Inf', '')"},
+		{"where uid = ${last}", "where uid = 501"},
+		{"where uid = ${last[1]}", "where uid = 0"},
+		{"where uid = $last", "where uid = 501"},
+		{"where uid = $last and 1", "where uid = 501 and 1"},
+		{"where uid = ${last}${last[1]}", "where uid = 5010"},
+		{`where uid = \${last}`, "where uid = ${last}"},
+		{`where uid = \$last`, "where uid = $last"},
+		{"where name = '$HOME'", "where name = '$HOME'"},
+		{`where name = '\$HOME'`, `where name = '\$HOME'`},
+		{"select json_extract(data, '$.name')", "select json_extract(data, '$.name')"},
+		{"where name ~ '^sshd$'", "where name ~ '^sshd$'"},
+		{"where uid = $lastly", "where uid = $lastly"},
+		{"where uid = $#", "where uid = $#"},
+	}
+	for _, test := range tests {
+		got, err := InterpolateVariables(test.line, lookupTestVariable)
+		if err != nil {
+			t.Errorf("InterpolateVariables(%q) failed: %s", test.line, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("InterpolateVariables(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestInterpolateVariablesErrors(t *testing.T) {
+	tests := []string{
+		"${missing}",
+		"${procs}",
+		"$procs",
+		"${procs.missing}",
+		"${procs[3].pid}",
+		"${procs[-4].pid}",
+		"${empty}",
+	}
+	for _, line := range tests {
+		if got, err := InterpolateVariables(line, lookupTestVariable); err == nil {
+			t.Errorf("InterpolateVariables(%q) = %q, want an error", line, got)
+		}
+	}
+}